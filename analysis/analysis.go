@@ -0,0 +1,306 @@
+// Package analysis serves scored move lists from a solved database over HTTP
+package analysis
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/pcarrier/teeko.cc/bot"
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Largest number of boards accepted in one batch request
+const maxBatch = 256
+
+// Largest batch request body, leaving room for long histories
+const maxBatchBytes = 1 << 20
+
+// Largest body of a request for one board
+const maxBoardBytes = 64 << 10
+
+// Outcome of a score from the mover's perspective, decoded like formatScore in bot.ts
+type Outcome struct {
+	Score    int8   `json:"score"`
+	Outcome  string `json:"outcome"`            // "win", "loss", "draw" or "unknown"
+	Distance *int   `json:"distance,omitempty"` // moves to the forced outcome, absent for draws
+}
+
+// Distance is teeko.Distance for move scores, teeko.PositionDistance for positions
+func outcome(score int8, distance func(int8) (int, bool)) Outcome {
+	o := Outcome{Score: score, Outcome: "draw"}
+	if score == teeko.ScoreNone {
+		o.Outcome = "unknown"
+	} else if d, ok := distance(score); ok {
+		o.Distance = &d
+		if score > 0 {
+			o.Outcome = "win"
+		} else {
+			o.Outcome = "loss"
+		}
+	}
+	return o
+}
+
+// Move is an entry of generateMoves in bot.ts with its decoded outcome
+type Move struct {
+	From *int `json:"from,omitempty"`
+	To   int  `json:"to"`
+	Outcome
+}
+
+// Result is the analysis of one board
+type Result struct {
+	Outcome
+	GameOver bool   `json:"gameOver,omitempty"`
+	Moves    []Move `json:"moves"` // best first
+}
+
+// Server answers analysis requests from a database, caching hot positions
+type Server struct {
+	db    *teeko.DB
+	cache *lru
+}
+
+// New returns a server for db keeping up to cacheSize results.
+// Lookups are much faster once db.BuildIndex has been called.
+func New(db *teeko.DB, cacheSize int) *Server {
+	return &Server{db: db, cache: newLRU(cacheSize)}
+}
+
+// Analyze scores a board and each of its moves
+func (s *Server) Analyze(board teeko.Board) *Result {
+	mover, other := board.Mover()
+	key := uint64(mover)<<32 | uint64(other)
+	if r, ok := s.cache.get(key); ok {
+		return r
+	}
+
+	r := &Result{Outcome: outcome(s.db.Score(mover, other), teeko.PositionDistance), Moves: []Move{}}
+	if teeko.IsWin(board.A) || teeko.IsWin(board.B) {
+		r.GameOver = true
+	} else {
+		moves := s.db.Moves(board)
+		slices.SortStableFunc(moves, func(x, y teeko.Move) int { return int(y.Score) - int(x.Score) })
+		for _, m := range moves {
+			mv := Move{To: m.To, Outcome: outcome(m.Score, teeko.Distance)}
+			if !m.IsDrop() {
+				from := m.From
+				mv.From = &from
+			}
+			r.Moves = append(r.Moves, mv)
+		}
+	}
+	s.cache.put(key, r)
+	return r
+}
+
+// Handler routes:
+//
+//	GET  /analyze?a=<bitset>&b=<bitset>&turn=a|b
+//	POST /analyze   body: Board {a, b, m, p}
+//	POST /batch     body: [Board, …], answered with [Result, …]
+//	POST /bot?difficulty=medium   body: Board, answered with {from?, to, score}
+//
+// Any origin may call them, so that web pages can query a local server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /analyze", s.getAnalyze)
+	mux.HandleFunc("POST /analyze", s.postAnalyze)
+	mux.HandleFunc("POST /batch", s.postBatch)
+	mux.HandleFunc("POST /bot", s.postBot)
+	return cors(mux)
+}
+
+// Allow cross-origin requests, answering preflight requests directly
+func cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+			w.Header().Set("Access-Control-Max-Age", "86400")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) getAnalyze(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	a, errA := strconv.ParseUint(q.Get("a"), 10, 32)
+	b, errB := strconv.ParseUint(q.Get("b"), 10, 32)
+	if errA != nil || errB != nil {
+		http.Error(w, "a and b must be bitsets", http.StatusBadRequest)
+		return
+	}
+	board := teeko.Board{A: uint32(a), B: uint32(b)}
+	switch q.Get("turn") {
+	case "", "a":
+	case "b":
+		// Only the parity of the history matters for analysis
+		board.M = []teeko.Action{teeko.UnknownAction}
+	default:
+		http.Error(w, "turn must be a or b", http.StatusBadRequest)
+		return
+	}
+	s.respond(w, []teeko.Board{board}, false)
+}
+
+func (s *Server) postAnalyze(w http.ResponseWriter, r *http.Request) {
+	var board teeko.Board
+	if !decode(w, r, maxBoardBytes, &board) {
+		return
+	}
+	s.respond(w, []teeko.Board{board}, false)
+}
+
+func (s *Server) postBatch(w http.ResponseWriter, r *http.Request) {
+	var boards []teeko.Board
+	if !decode(w, r, maxBatchBytes, &boards) {
+		return
+	}
+	if len(boards) > maxBatch {
+		http.Error(w, fmt.Sprintf("at most %d boards per batch", maxBatch), http.StatusBadRequest)
+		return
+	}
+	s.respond(w, boards, true)
+}
+
+func (s *Server) postBot(w http.ResponseWriter, r *http.Request) {
+	difficulty := bot.Difficulty(r.URL.Query().Get("difficulty"))
+	if difficulty == "" {
+		difficulty = bot.Medium
+	}
+	cfg, err := difficulty.Config()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var board teeko.Board
+	if !decode(w, r, maxBoardBytes, &board) {
+		return
+	}
+	if err := validate(board); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m, ok := bot.New(s.db, cfg, rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))).Move(board)
+	if !ok {
+		http.Error(w, "game over", http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m); err != nil {
+		log.Printf("analysis: writing response: %v", err)
+	}
+}
+
+// Decode a JSON body of at most limit bytes into v, answering the request
+// with an error otherwise
+func decode(w http.ResponseWriter, r *http.Request, limit int64, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(v); err != nil {
+		status := http.StatusBadRequest
+		if tooLarge := (*http.MaxBytesError)(nil); errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+		return false
+	}
+	return true
+}
+
+func (s *Server) respond(w http.ResponseWriter, boards []teeko.Board, batch bool) {
+	results := make([]*Result, len(boards))
+	for i, board := range boards {
+		if err := validate(board); err != nil {
+			http.Error(w, fmt.Sprintf("board %d: %v", i, err), http.StatusBadRequest)
+			return
+		}
+		results[i] = s.Analyze(board)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	var err error
+	if batch {
+		err = json.NewEncoder(w).Encode(results)
+	} else {
+		err = json.NewEncoder(w).Encode(results[0])
+	}
+	if err != nil {
+		log.Printf("analysis: writing response: %v", err)
+	}
+}
+
+// Reject boards the database cannot score
+func validate(board teeko.Board) error {
+	mover, other := board.Mover()
+	n, m := bits.OnesCount32(mover), bits.OnesCount32(other)
+	switch {
+	case board.A&board.B != 0:
+		return fmt.Errorf("overlapping pieces")
+	case (board.A|board.B)>>teeko.Size != 0:
+		return fmt.Errorf("pieces off the board")
+	case m > 4:
+		return fmt.Errorf("too many pieces")
+	case teeko.IsWin(board.A) && teeko.IsWin(board.B):
+		return fmt.Errorf("both players have won")
+	case n > m || m > n+1:
+		// The player to move never has more pieces than their opponent
+		return fmt.Errorf("piece counts do not match the player to move")
+	}
+	return nil
+}
+
+// Least recently used cache of results
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // front = most recent
+	items map[uint64]*list.Element
+}
+
+type lruEntry struct {
+	key    uint64
+	result *Result
+}
+
+func newLRU(size int) *lru {
+	return &lru{size: size, order: list.New(), items: make(map[uint64]*list.Element)}
+}
+
+func (c *lru) get(key uint64) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*lruEntry).result, true
+	}
+	return nil, false
+}
+
+func (c *lru) put(key uint64, r *Result) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key, r})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
@@ -0,0 +1,122 @@
+package analysis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Requests rejected before any lookup, so no database is needed
+func TestHandlerRejects(t *testing.T) {
+	h := New(nil, 0).Handler()
+	for _, tc := range []struct {
+		name, method, path, body string
+		status                   int
+	}{
+		{"preflight", http.MethodOptions, "/batch", "", http.StatusNoContent},
+		{"bad bitset", http.MethodGet, "/analyze?a=x&b=0", "", http.StatusBadRequest},
+		{"both won", http.MethodPost, "/analyze", `{"a":15,"b":983040,"m":[],"p":true}`, http.StatusBadRequest},
+		{"both won in batch", http.MethodPost, "/batch", `[{"a":15,"b":983040,"m":[],"p":true}]`, http.StatusBadRequest},
+		{"board too large", http.MethodPost, "/analyze", `{"a":0,"b":0,"p":true,"m":[` + strings.Repeat(`{"from":-1,"to":0},`, maxBoardBytes/18) + `{}]}`, http.StatusRequestEntityTooLarge},
+		{"bot board too large", http.MethodPost, "/bot", `{"m":[` + strings.Repeat(`{"to":0},`, maxBoardBytes/8) + `{}]}`, http.StatusRequestEntityTooLarge},
+		{"batch too large", http.MethodPost, "/batch", "[" + strings.Repeat(`{"a":0,"b":0,"m":[],"p":true},`, maxBatchBytes/20) + "{}]", http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+			if rec.Code != tc.status {
+				t.Errorf("status %d, want %d: %s", rec.Code, tc.status, rec.Body)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+				t.Errorf("Access-Control-Allow-Origin %q, want *", got)
+			}
+		})
+	}
+}
+
+func TestOutcomeDistance(t *testing.T) {
+	// A position won in one move, whose winning move scores one more
+	if o := outcome(125, teeko.PositionDistance); o.Outcome != "win" || *o.Distance != 1 {
+		t.Errorf("position 125: %s in %d, want win in 1", o.Outcome, *o.Distance)
+	}
+	if o := outcome(teeko.ScoreAWin, teeko.Distance); o.Outcome != "win" || *o.Distance != 1 {
+		t.Errorf("move 126: %s in %d, want win in 1", o.Outcome, *o.Distance)
+	}
+	if o := outcome(0, teeko.PositionDistance); o.Outcome != "draw" || o.Distance != nil {
+		t.Errorf("position 0: %s, want draw without distance", o.Outcome)
+	}
+	if o := outcome(teeko.ScoreNone, teeko.PositionDistance); o.Outcome != "unknown" || o.Distance != nil {
+		t.Errorf("position ScoreNone: %s, want unknown without distance", o.Outcome)
+	}
+}
+
+// A database of the empty board and its drops, where only c3 wins by force
+func openingDB(t *testing.T) (db *teeko.DB, c3 int) {
+	t.Helper()
+	db = &teeko.DB{}
+	for n := range 2 {
+		numBlocks := (teeko.Configs[n] + teeko.BLOCK_SIZE - 1) / teeko.BLOCK_SIZE
+		db.Checkpoints[n] = make([]int, numBlocks+1)
+		for g := range teeko.Configs[n] {
+			if teeko.IsCanonical(g, n) {
+				db.Counts[n]++
+			}
+		}
+		db.Checkpoints[n][numBlocks] = db.Counts[n]
+		db.Scores[n] = make([]int8, db.Counts[n])
+	}
+	c3, err := teeko.ParseSquare("c3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Red to move after c3 loses in 2, so c3 wins in 3 and so does the empty board
+	db.Store(0, 1<<c3, 1, -124)
+	db.Store(0, 0, 0, 123)
+	return db, c3
+}
+
+func TestAnalyze(t *testing.T) {
+	db, c3 := openingDB(t)
+	h := New(db, 16).Handler()
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/analyze?a=0&b=0", nil),
+		httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(`{"a":0,"b":0,"m":[],"p":true}`)),
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("%s %s: status %d, %s", req.Method, req.URL, rec.Code, rec.Body)
+		}
+		var r Result
+		if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r.Score != 123 || r.Outcome.Outcome != "win" || r.Distance == nil || *r.Distance != 3 || r.GameOver {
+			t.Errorf("%s %s: got %+v, want a win in 3", req.Method, req.URL, r.Outcome)
+		}
+		if len(r.Moves) != teeko.Size {
+			t.Fatalf("%d moves, want %d", len(r.Moves), teeko.Size)
+		}
+		best := r.Moves[0]
+		if best.To != c3 || best.From != nil || best.Score != 124 || best.Outcome.Outcome != "win" || *best.Distance != 3 {
+			t.Errorf("best move %+v, want the c3 drop winning in 3", best)
+		}
+		if next := r.Moves[1]; next.Outcome.Outcome != "draw" || next.Distance != nil {
+			t.Errorf("second move %+v, want a draw", next)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bot?difficulty=perfect", strings.NewReader(`{"a":0,"b":0,"m":[],"p":true}`)))
+	var m teeko.Move
+	if err := json.Unmarshal(rec.Body.Bytes(), &m); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("bot: status %d, %s", rec.Code, rec.Body)
+	}
+	if m.To != c3 || m.Score != 124 {
+		t.Errorf("bot played %+v, want c3", m)
+	}
+}
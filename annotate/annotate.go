@@ -0,0 +1,151 @@
+// Package annotate grades each move of a game against the solved database
+package annotate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Class grades a move by how much it gave up compared to the best one
+type Class int
+
+const (
+	Best       Class = iota // as good as any move
+	Good                    // slightly slower win, or slightly weaker draw
+	Inaccuracy              // clearly slower win, faster loss, or weaker draw
+	Mistake                 // much weaker draw
+	Blunder                 // turned a win into a draw or loss, or a draw into a loss
+)
+
+var classNames = [...]string{"best", "good", "inaccuracy", "mistake", "blunder"}
+
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return fmt.Sprintf("class(%d)", int(c))
+	}
+	return classNames[c]
+}
+
+func (c Class) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Class) UnmarshalText(text []byte) error {
+	for i, name := range classNames {
+		if string(text) == name {
+			*c = Class(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown move class %q", text)
+}
+
+// Thresholds between classes, in plies for forced outcomes and in heuristic points for draws
+const (
+	goodPlies      = 2
+	goodPoints     = 10
+	mistakePoints  = 25
+	forcedMinScore = teeko.ScoreHeuristicMax + 1
+)
+
+// Annotation describes one move of a game; scores are from the mover's perspective
+type Annotation struct {
+	Ply       int          `json:"ply"`
+	Action    teeko.Action `json:"action"`
+	Score     int8         `json:"score"`
+	Best      teeko.Action `json:"best"` // first of the best moves in GenerateMoves order
+	BestScore int8         `json:"bestScore"`
+	Swing     int          `json:"swing"` // BestScore - Score
+	Class     Class        `json:"class"`
+}
+
+// Game annotates every move of a history, which must follow the rules
+func Game(t teeko.ScoreTable, history []teeko.Action) ([]Annotation, error) {
+	board := teeko.Board{P: true}
+	annotations := make([]Annotation, 0, len(history))
+	for i, action := range history {
+		next, err := board.Apply(action)
+		if err != nil {
+			return annotations, err
+		}
+
+		a := Annotation{Ply: i, Action: action, BestScore: teeko.ScoreNone}
+		for _, m := range teeko.GenerateMoves(t, board) {
+			if m.Action == action {
+				a.Score = m.Score
+			}
+			if a.BestScore == teeko.ScoreNone || m.Score > a.BestScore {
+				a.Best, a.BestScore = m.Action, m.Score
+			}
+		}
+		a.Swing = int(a.BestScore) - int(a.Score)
+		a.Class = classify(a.BestScore, a.Score)
+		annotations = append(annotations, a)
+		board = next
+	}
+	return annotations, nil
+}
+
+// Outcome of a score: 1 for a forced win, -1 for a forced loss, 0 for a draw
+func outcome(score int8) int {
+	switch {
+	case score >= forcedMinScore:
+		return 1
+	case score <= -forcedMinScore:
+		return -1
+	}
+	return 0
+}
+
+func classify(best, played int8) Class {
+	diff := int(best) - int(played)
+	switch {
+	case diff <= 0:
+		return Best
+	case outcome(played) < outcome(best):
+		return Blunder
+	case outcome(best) != 0:
+		// Same forced outcome, reached later when winning or sooner when losing
+		if diff <= goodPlies {
+			return Good
+		}
+		return Inaccuracy
+	case diff <= goodPoints:
+		return Good
+	case diff <= mistakePoints:
+		return Inaccuracy
+	}
+	return Mistake
+}
+
+// Summary counts the moves of each class per player
+type Summary struct {
+	Blue, Red [len(classNames)]int
+}
+
+// Summarize counts classes, Blue playing the even plies
+func Summarize(annotations []Annotation) Summary {
+	var s Summary
+	for _, a := range annotations {
+		if a.Ply%2 == 0 {
+			s.Blue[a.Class]++
+		} else {
+			s.Red[a.Class]++
+		}
+	}
+	return s
+}
+
+// MarshalJSON writes each side as an object from class name to count
+func (s Summary) MarshalJSON() ([]byte, error) {
+	side := func(counts [len(classNames)]int) map[string]int {
+		m := make(map[string]int, len(counts))
+		for c, n := range counts {
+			m[classNames[c]] = n
+		}
+		return m
+	}
+	return json.Marshal(map[string]map[string]int{"blue": side(s.Blue), "red": side(s.Red)})
+}
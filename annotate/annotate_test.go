@@ -0,0 +1,118 @@
+package annotate
+
+import (
+	"testing"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+func TestClassText(t *testing.T) {
+	for c := Best; c <= Blunder; c++ {
+		text, err := c.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var back Class
+		if err := back.UnmarshalText(text); err != nil || back != c {
+			t.Errorf("%v round-tripped as %v, %v", c, back, err)
+		}
+	}
+	// Out of range classes still print
+	for c, want := range map[Class]string{-1: "class(-1)", Blunder + 1: "class(5)"} {
+		if got := c.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		best, played int8
+		want         Class
+	}{
+		{teeko.ScoreAWin, teeko.ScoreAWin, Best},
+		{30, 31, Best},
+		// Slower wins and faster losses, in plies
+		{teeko.ScoreAWin, teeko.ScoreAWin - goodPlies, Good},
+		{teeko.ScoreAWin, teeko.ScoreAWin - goodPlies - 1, Inaccuracy},
+		{forcedMinScore + 10, forcedMinScore, Inaccuracy},
+		{-100, -100 - goodPlies, Good},
+		{-100, -100 - goodPlies - 1, Inaccuracy},
+		// Weaker draws, in points
+		{30, 30 - goodPoints, Good},
+		{30, 30 - goodPoints - 1, Inaccuracy},
+		{30, 30 - mistakePoints, Inaccuracy},
+		{30, 30 - mistakePoints - 1, Mistake},
+		{teeko.ScoreHeuristicMax, -teeko.ScoreHeuristicMax, Mistake},
+		// Worse outcomes, however close the scores
+		{forcedMinScore, forcedMinScore - 1, Blunder},
+		{teeko.ScoreAWin, teeko.ScoreBWin, Blunder},
+		{-teeko.ScoreHeuristicMax, -forcedMinScore, Blunder},
+	} {
+		if got := classify(tc.best, tc.played); got != tc.want {
+			t.Errorf("classify(%d, %d) = %v, want %v", tc.best, tc.played, got, tc.want)
+		}
+	}
+}
+
+// Scores of a few positions, every other one drawn at 0
+type stubTable map[teeko.Position]int8
+
+func (s stubTable) Lookup(a, b uint32, n int) int8 {
+	return s[teeko.Position{A: a, B: b}]
+}
+
+func square(t *testing.T, name string) uint32 {
+	t.Helper()
+	sq, err := teeko.ParseSquare(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 1 << sq
+}
+
+func TestGame(t *testing.T) {
+	c3, c4 := square(t, "c3"), square(t, "c4")
+	// Positions are scored for the player to move, so negated for the move leading there
+	tt := stubTable{
+		{A: 0, B: square(t, "a1")}:  -40,
+		{A: c3, B: c4}:              -20,
+		{A: c3, B: square(t, "b2")}: -25,
+	}
+	history, err := teeko.ParseActions("c3 c4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations, err := Game(tt, history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		best         string
+		score, bestS int8
+		class        Class
+	}{
+		{"a1", 0, 40, Mistake},
+		{"b2", 20, 25, Good},
+	}
+	if len(annotations) != len(want) {
+		t.Fatalf("%d annotations, want %d", len(annotations), len(want))
+	}
+	for i, w := range want {
+		a := annotations[i]
+		best, _ := teeko.ParseAction(w.best)
+		if a.Ply != i || a.Action != history[i] || a.Best != best || a.Score != w.score ||
+			a.BestScore != w.bestS || a.Swing != int(w.bestS-w.score) || a.Class != w.class {
+			t.Errorf("ply %d: got %+v, want best %s, scores %d and %d, %v", i, a, w.best, w.score, w.bestS, w.class)
+		}
+	}
+	if s := Summarize(annotations); s.Blue[Mistake] != 1 || s.Red[Good] != 1 {
+		t.Errorf("summary %+v", s)
+	}
+
+	// Annotations stop at the first illegal action
+	history = append(history, history[0])
+	if annotations, err := Game(tt, history); err == nil || len(annotations) != 2 {
+		t.Errorf("%d annotations and error %v for a drop on an occupied square", len(annotations), err)
+	}
+}
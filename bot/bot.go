@@ -0,0 +1,152 @@
+// Package bot picks moves from database scores like selectMove in ui/src/bot.ts
+package bot
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Difficulty names a Config, as Difficulty in bot.ts
+type Difficulty string
+
+const (
+	Beginner Difficulty = "beginner"
+	Easy     Difficulty = "easy"
+	Medium   Difficulty = "medium"
+	Hard     Difficulty = "hard"
+	Perfect  Difficulty = "perfect"
+)
+
+// Difficulties from weakest to strongest
+var Difficulties = []Difficulty{Beginner, Easy, Medium, Hard, Perfect}
+
+// Config tunes how far the bot strays from the best move
+type Config struct {
+	Perfect      bool    `json:"perfect,omitempty"` // always play a best move, ignoring the rest
+	MissBlunders float64 `json:"missBlunders"`      // chance of not ruling out moves that lose by force
+	Randomness   float64 `json:"randomness"`        // chance of a uniformly random move when nothing is forced
+	TopFraction  float64 `json:"topFraction"`       // share of the candidates, best first, to choose among
+	Bias         float64 `json:"bias"`              // exponent favouring the better of those candidates
+	Name         string  `json:"name,omitempty"`    // for reports
+}
+
+var configs = map[Difficulty]Config{
+	Beginner: {MissBlunders: 0.3, Randomness: 0.7, TopFraction: 0.5, Bias: 0.5},
+	Easy:     {MissBlunders: 0.2, Randomness: 0.5, TopFraction: 0.3, Bias: 1},
+	Medium:   {MissBlunders: 0.1, Randomness: 0.2, TopFraction: 0.25, Bias: 2},
+	Hard:     {MissBlunders: 0.05, Randomness: 0.05, TopFraction: 0.2, Bias: 3},
+	Perfect:  {Perfect: true},
+}
+
+// Config returns the settings of a difficulty
+func (d Difficulty) Config() (Config, error) {
+	c, ok := configs[d]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown difficulty %q (want one of %v)", d, Difficulties)
+	}
+	c.Name = string(d)
+	return c, nil
+}
+
+// Rand is the source of randomness, such as *math/rand/v2.Rand;
+// Float64 returns a number in [0, 1) like Math.random
+type Rand interface {
+	Float64() float64
+}
+
+// Bot plays moves scored by a database
+type Bot struct {
+	Table  teeko.ScoreTable
+	Config Config
+	Rand   Rand
+}
+
+// New returns a bot playing with cfg, drawing from rng
+func New(t teeko.ScoreTable, cfg Config, rng Rand) *Bot {
+	return &Bot{Table: t, Config: cfg, Rand: rng}
+}
+
+// Move picks an action for the player to move, like getBotMove;
+// ok is false when the game is over
+func (b *Bot) Move(board teeko.Board) (m teeko.Move, ok bool) {
+	if teeko.IsWin(board.A) || teeko.IsWin(board.B) {
+		return m, false
+	}
+	moves := teeko.GenerateMoves(b.Table, board)
+	if len(moves) == 0 {
+		return m, false
+	}
+	return b.Select(moves), true
+}
+
+// Select picks one of the scored moves, drawing random numbers in the same
+// order as selectMove so that a replayed sequence gives the same choices
+func (b *Bot) Select(moves []teeko.Move) teeko.Move {
+	sorted := slices.Clone(moves)
+	b.shuffle(sorted)
+	slices.SortStableFunc(sorted, func(x, y teeko.Move) int { return int(y.Score) - int(x.Score) })
+
+	cfg := b.Config
+	if cfg.Perfect {
+		return sorted[0]
+	}
+
+	hasForced := slices.ContainsFunc(sorted, func(m teeko.Move) bool {
+		return m.Score > teeko.ScoreHeuristicMax || m.Score < -teeko.ScoreHeuristicMax
+	})
+	missBlunders := cfg.MissBlunders > 0 && b.Rand.Float64() < cfg.MissBlunders
+
+	// Rule out forced losses unless blundering
+	candidates := sorted
+	if !missBlunders {
+		safe := slices.DeleteFunc(slices.Clone(sorted), func(m teeko.Move) bool {
+			return m.Score < -teeko.ScoreHeuristicMax
+		})
+		if len(safe) > 0 {
+			candidates = safe
+		}
+	}
+
+	// With only heuristic scores, weaker bots often play at random
+	randomness := cfg.Randomness
+	if hasForced {
+		randomness = 0
+	}
+	if b.Rand.Float64() < randomness {
+		return candidates[int(b.Rand.Float64()*float64(len(candidates)))]
+	}
+
+	top := max(1, int(math.Ceil(float64(len(candidates))*cfg.TopFraction)))
+	return b.weightedRandom(candidates[:min(top, len(candidates))], cfg.Bias)
+}
+
+// Fisher-Yates, as shuffle in bot.ts
+func (b *Bot) shuffle(moves []teeko.Move) {
+	for i := len(moves) - 1; i > 0; i-- {
+		j := int(b.Rand.Float64() * float64(i+1))
+		moves[i], moves[j] = moves[j], moves[i]
+	}
+}
+
+// Pick a move, the i-th of n weighing (n-i)^bias
+func (b *Bot) weightedRandom(moves []teeko.Move, bias float64) teeko.Move {
+	if len(moves) == 1 {
+		return moves[0]
+	}
+	weights := make([]float64, len(moves))
+	total := 0.0
+	for i := range moves {
+		weights[i] = math.Pow(float64(len(moves)-i), bias)
+		total += weights[i]
+	}
+	r := b.Rand.Float64() * total
+	for i, w := range weights {
+		if r -= w; r <= 0 {
+			return moves[i]
+		}
+	}
+	return moves[len(moves)-1]
+}
@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Scored drops, one of them losing by force
+var moves = []teeko.Move{
+	{Action: teeko.Drop(0), Score: 10},
+	{Action: teeko.Drop(1), Score: -5},
+	{Action: teeko.Drop(2), Score: 30},
+	{Action: teeko.Drop(3), Score: teeko.ScoreBWin + 2},
+	{Action: teeko.Drop(4), Score: 0},
+	{Action: teeko.Drop(5), Score: 20},
+}
+
+func pick(cfg Config, seed uint64, moves []teeko.Move) teeko.Move {
+	return New(nil, cfg, rand.New(rand.NewPCG(seed, 0))).Select(moves)
+}
+
+func TestSelectPerfect(t *testing.T) {
+	cfg, _ := Perfect.Config()
+	for seed := range uint64(100) {
+		if m := pick(cfg, seed, moves); m.Score != 30 {
+			t.Fatalf("seed %d: picked a move scoring %d, want 30", seed, m.Score)
+		}
+	}
+}
+
+func TestSelectBlunders(t *testing.T) {
+	// Uniform among every candidate
+	careful := Config{TopFraction: 1}
+	careless := Config{MissBlunders: 1, TopFraction: 1}
+	blunders := 0
+	for seed := range uint64(200) {
+		if m := pick(careful, seed, moves); m.Score < -teeko.ScoreHeuristicMax {
+			t.Fatalf("seed %d: picked the forced loss", seed)
+		}
+		if m := pick(careless, seed, moves); m.Score < -teeko.ScoreHeuristicMax {
+			blunders++
+		}
+	}
+	if blunders == 0 {
+		t.Error("missing every blunder, the forced loss was never picked")
+	}
+
+	// Only forced losses left, one of them is played
+	losing := []teeko.Move{{Action: teeko.Drop(0), Score: -100}, {Action: teeko.Drop(1), Score: -90}}
+	if m := pick(careful, 1, losing); m.Score != -90 && m.Score != -100 {
+		t.Errorf("picked %v among forced losses", m)
+	}
+}
+
+func TestSelectTopFraction(t *testing.T) {
+	// Half of the five safe moves rounds up to the three best
+	cfg := Config{TopFraction: 0.5}
+	seen := map[int8]bool{}
+	for seed := range uint64(200) {
+		m := pick(cfg, seed, moves)
+		if m.Score < 10 {
+			t.Fatalf("seed %d: picked a move scoring %d outside the top half", seed, m.Score)
+		}
+		seen[m.Score] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("picked scores %v, want each of 30, 20 and 10", seen)
+	}
+}
+
+// Returns the same number forever
+type fixed float64
+
+func (f fixed) Float64() float64 { return float64(f) }
+
+func TestWeightedRandom(t *testing.T) {
+	top := moves[:3]
+	// With bias 1, the three moves weigh 3, 2 and 1 out of 6
+	for _, tc := range []struct {
+		r    float64
+		bias float64
+		want int
+	}{
+		{0, 1, 0},
+		{0.5, 1, 0},
+		{0.51, 1, 1},
+		{0.83, 1, 1},
+		{0.84, 1, 2},
+		{0.99, 1, 2},
+		// Bias 0 weighs them all the same
+		{0.34, 0, 1},
+		// Bias 3: 27, 8 and 1 out of 36
+		{0.75, 3, 0},
+		{0.76, 3, 1},
+	} {
+		b := New(nil, Config{}, fixed(tc.r))
+		if m := b.weightedRandom(top, tc.bias); m != top[tc.want] {
+			t.Errorf("r=%v bias=%v: picked %v, want %v", tc.r, tc.bias, m, top[tc.want])
+		}
+	}
+}
+
+func TestSelectSeeded(t *testing.T) {
+	for _, d := range Difficulties {
+		cfg, _ := d.Config()
+		for seed := range uint64(50) {
+			x, y := New(nil, cfg, rand.New(rand.NewPCG(seed, 0))), New(nil, cfg, rand.New(rand.NewPCG(seed, 0)))
+			for range 5 {
+				if mx, my := x.Select(moves), y.Select(moves); mx != my {
+					t.Fatalf("%s, seed %d: picked %v then %v", d, seed, mx, my)
+				}
+			}
+		}
+	}
+}
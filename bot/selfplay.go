@@ -0,0 +1,180 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// ParseConfig reads a difficulty name or a Config as JSON.
+// Configs without a name are labelled by Label.
+func ParseConfig(s string) (Config, error) {
+	if !strings.HasPrefix(strings.TrimSpace(s), "{") {
+		return Difficulty(s).Config()
+	}
+	var c Config
+	if err := json.Unmarshal([]byte(s), &c); err != nil {
+		return c, fmt.Errorf("bot config: %w", err)
+	}
+	if c.Name == "" {
+		c.Name = c.Label()
+	}
+	return c, nil
+}
+
+// Label names a config for reports: the difficulty it plays like, or its parameters
+func (c Config) Label() string {
+	c.Name = ""
+	for _, d := range Difficulties {
+		if dc := configs[d]; c == dc || c.Perfect && dc.Perfect {
+			return string(d)
+		}
+	}
+	return fmt.Sprintf("custom(missBlunders=%g,randomness=%g,topFraction=%g,bias=%g)", c.MissBlunders, c.Randomness, c.TopFraction, c.Bias)
+}
+
+// Game is the outcome of a self-play game
+type Game struct {
+	Actions []teeko.Action
+	Winner  int // 0 for Blue, 1 for Red, -1 for a draw by move limit
+}
+
+// PlayGame plays from the empty board until a win or maxPlies actions
+func PlayGame(t teeko.ScoreTable, blue, red Config, rng Rand, maxPlies int) Game {
+	bots := [2]*Bot{New(t, blue, rng), New(t, red, rng)}
+	board := teeko.Board{P: true}
+	for len(board.M) < maxPlies {
+		m, ok := bots[len(board.M)%2].Move(board)
+		if !ok {
+			break
+		}
+		next, err := board.Apply(m.Action)
+		if err != nil {
+			panic(err)
+		}
+		board = next
+	}
+	g := Game{Actions: board.M, Winner: -1}
+	switch {
+	case teeko.IsWin(board.A):
+		g.Winner = 0
+	case teeko.IsWin(board.B):
+		g.Winner = 1
+	}
+	return g
+}
+
+// Match describes games between two configs, each playing Blue in half of them
+type Match struct {
+	A, B     Config
+	Games    int
+	Seed     uint64
+	MaxPlies int
+	Workers  int
+}
+
+// MatchResult is from the point of view of A
+type MatchResult struct {
+	A         string  `json:"a"`
+	B         string  `json:"b"`
+	Games     int     `json:"games"`
+	Wins      int     `json:"wins"`
+	Draws     int     `json:"draws"`
+	Losses    int     `json:"losses"`
+	WinRate   float64 `json:"winRate"`
+	DrawRate  float64 `json:"drawRate"`
+	LossRate  float64 `json:"lossRate"`
+	AvgPlies  float64 `json:"avgPlies"`
+	Elo       float64 `json:"elo"`       // rating of A minus rating of B
+	EloMargin float64 `json:"eloMargin"` // half width of the 95% confidence interval
+	FirstWins int     `json:"firstWins"` // games won by Blue, whoever played it
+	Seed      uint64  `json:"seed"`
+}
+
+// Play runs the match. Game i uses its own generator seeded from Seed and i,
+// so results do not depend on the number of workers.
+func (m Match) Play(t teeko.ScoreTable, each func(i int, aIsBlue bool, g Game)) MatchResult {
+	workers := m.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	games := make([]Game, m.Games)
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				rng := rand.New(rand.NewPCG(m.Seed, uint64(i)))
+				blue, red := m.A, m.B
+				if i%2 == 1 {
+					blue, red = red, blue
+				}
+				games[i] = PlayGame(t, blue, red, rng, m.MaxPlies)
+			}
+		}()
+	}
+	for i := range m.Games {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	r := MatchResult{A: m.A.Name, B: m.B.Name, Games: m.Games, Seed: m.Seed}
+	plies := 0
+	for i, g := range games {
+		aIsBlue := i%2 == 0
+		if each != nil {
+			each(i, aIsBlue, g)
+		}
+		plies += len(g.Actions)
+		switch {
+		case g.Winner < 0:
+			r.Draws++
+		case (g.Winner == 0) == aIsBlue:
+			r.Wins++
+		default:
+			r.Losses++
+		}
+		if g.Winner == 0 {
+			r.FirstWins++
+		}
+	}
+	if m.Games > 0 {
+		n := float64(m.Games)
+		r.WinRate = float64(r.Wins) / n
+		r.DrawRate = float64(r.Draws) / n
+		r.LossRate = float64(r.Losses) / n
+		r.AvgPlies = float64(plies) / n
+		r.Elo, r.EloMargin = eloGap(r.Wins, r.Draws, r.Losses)
+	}
+	return r
+}
+
+// Elo difference implied by a score, with the margin of its 95% confidence interval
+func eloGap(wins, draws, losses int) (elo, margin float64) {
+	n := float64(wins + draws + losses)
+	score := (float64(wins) + float64(draws)/2) / n
+	// Per-game variance of the score
+	variance := (float64(wins)*math.Pow(1-score, 2) + float64(draws)*math.Pow(0.5-score, 2) +
+		float64(losses)*math.Pow(score, 2)) / n
+	se := math.Sqrt(variance / n)
+	elo = eloOf(score, n)
+	margin = (eloOf(score+1.96*se, n) - eloOf(score-1.96*se, n)) / 2
+	return elo, margin
+}
+
+// Elo difference for an expected score, clamped away from 0 and 1 by half a game
+// out of n+1, so that even a single game keeps its sign
+func eloOf(score, n float64) float64 {
+	lo := 0.5 / (n + 1)
+	score = math.Min(math.Max(score, lo), 1-lo)
+	return -400 * math.Log10(1/score-1)
+}
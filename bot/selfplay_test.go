@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+func TestParseConfigName(t *testing.T) {
+	for _, tc := range []struct {
+		config, name string
+	}{
+		{"hard", "hard"},
+		{`{"name": "cautious", "missBlunders": 0.01}`, "cautious"},
+		{`{"missBlunders": 0.05, "randomness": 0.05, "topFraction": 0.2, "bias": 3}`, "hard"},
+		{`{"perfect": true, "randomness": 0.5}`, "perfect"},
+		{`{"missBlunders": 0.1, "randomness": 0, "topFraction": 0.5, "bias": 1}`, "custom(missBlunders=0.1,randomness=0,topFraction=0.5,bias=1)"},
+	} {
+		c, err := ParseConfig(tc.config)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.config, err)
+		}
+		if c.Name != tc.name {
+			t.Errorf("%s: named %q, want %q", tc.config, c.Name, tc.name)
+		}
+	}
+	if _, err := ParseConfig("godlike"); err == nil {
+		t.Error("unknown difficulty accepted")
+	}
+}
+
+// Draws scored by a hash of the position, so that games vary with the seed;
+// wins are seen one ply ahead
+type hashTable struct{}
+
+func (hashTable) Lookup(a, b uint32, n int) int8 {
+	if teeko.IsWin(b) {
+		return teeko.ScoreBWin
+	}
+	h := (uint64(a)<<32 | uint64(b)) * 0x9e3779b97f4a7c15
+	return int8(h>>58) - 32
+}
+
+func TestMatchWorkers(t *testing.T) {
+	easy, _ := Easy.Config()
+	medium, _ := Medium.Config()
+	m := Match{A: easy, B: medium, Games: 40, Seed: 7, MaxPlies: 60, Workers: 1}
+	var want []Game
+	one := m.Play(hashTable{}, func(i int, aIsBlue bool, g Game) {
+		if aIsBlue != (i%2 == 0) {
+			t.Errorf("game %d: A playing Blue is %v", i, aIsBlue)
+		}
+		want = append(want, g)
+	})
+	if one.Wins+one.Draws+one.Losses != m.Games {
+		t.Fatalf("+%d =%d -%d out of %d games", one.Wins, one.Draws, one.Losses, m.Games)
+	}
+
+	m.Workers = 4
+	i := 0
+	many := m.Play(hashTable{}, func(_ int, _ bool, g Game) {
+		if !slices.Equal(g.Actions, want[i].Actions) || g.Winner != want[i].Winner {
+			t.Errorf("game %d differs with 4 workers", i)
+		}
+		i++
+	})
+	if many != one {
+		t.Errorf("1 worker: %+v, 4 workers: %+v", one, many)
+	}
+}
+
+func TestEloGap(t *testing.T) {
+	for _, tc := range []struct {
+		wins, draws, losses int
+		sign                float64
+	}{
+		{5, 0, 5, 0},
+		{0, 10, 0, 0},
+		{3, 4, 3, 0},
+		{6, 2, 2, 1},
+		{2, 2, 6, -1},
+		{10, 0, 0, 1},
+		{0, 0, 10, -1},
+		{1, 0, 0, 1},
+	} {
+		elo, margin := eloGap(tc.wins, tc.draws, tc.losses)
+		if math.IsNaN(elo) || math.IsInf(elo, 0) || math.IsNaN(margin) || math.IsInf(margin, 0) || margin < 0 {
+			t.Errorf("+%d =%d -%d: Elo %v ± %v", tc.wins, tc.draws, tc.losses, elo, margin)
+			continue
+		}
+		sign := 0.0
+		if elo > 0 {
+			sign = 1
+		} else if elo < 0 {
+			sign = -1
+		}
+		if sign != tc.sign {
+			t.Errorf("+%d =%d -%d: Elo %v, want sign %v", tc.wins, tc.draws, tc.losses, elo, tc.sign)
+		}
+	}
+	// Winning all of more games is stronger evidence
+	few, _ := eloGap(10, 0, 0)
+	more, _ := eloGap(100, 0, 0)
+	if more <= few {
+		t.Errorf("Elo %v after 100 wins, %v after 10", more, few)
+	}
+	if lost, _ := eloGap(0, 0, 10); math.Abs(few+lost) > 1e-9 {
+		t.Errorf("Elo %v after 10 wins, %v after 10 losses", few, lost)
+	}
+}
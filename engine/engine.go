@@ -0,0 +1,294 @@
+// Package engine speaks the Teeko Engine Protocol, a line-based text protocol
+// modelled on UCI that lets GUIs and tournament scripts drive the solver.
+//
+// The GUI sends one command per line on standard input:
+//
+//	tep                        identify; answered by id lines and tepok
+//	isready                    answered by readyok
+//	setoption name difficulty value <name>
+//	                           set the difficulty of later go commands
+//	newgame                    forget the current game
+//	position startpos [moves c3 c4 …]
+//	position <rows> <a|b> <drop|move> [moves …]
+//	                           set the position from the empty board or from a
+//	                           position string such as AAA../BB.../...../...../..... b drop;
+//	                           go fails after an invalid one until the next position
+//	go [difficulty <name>] [movetime <ms>] [depth <plies>]
+//	                           pick a move for the player to move
+//	stop                       accepted for compatibility; go always completes
+//	quit                       end the session
+//
+// and the engine answers go with an info line then the chosen action:
+//
+//	info depth 0 score 123 outcome win distance 4 pv c3-d4 b2-b3 d4-d5
+//	bestmove c3-d4
+//
+// Scores and distances are from the mover's perspective, as in the query command.
+// Depth 0 means the database answered; without one, depth is the search horizon
+// and the outcome is only certain for forced wins and losses. When the game is over
+// the engine answers "bestmove none". Malformed commands get an "info string" error.
+package engine
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pcarrier/teeko.cc/bot"
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Name reported by the tep command
+const Name = "teeko.cc solver"
+
+// Search limits of go commands without a database
+const (
+	DefaultDepth    = 6
+	DefaultMoveTime = 5 * time.Second
+)
+
+// Plies of the principal variation shown for drawn positions
+const pvPlies = 8
+
+// Engine answers protocol commands for one session
+type Engine struct {
+	Table teeko.ScoreTable // nil to search without a database
+	Rand  bot.Rand         // for difficulties below perfect
+	board teeko.Board
+	// Set when the last position command failed, so go does not answer for an older position
+	noPosition bool
+	difficulty bot.Difficulty // default of go commands
+}
+
+// New returns an engine at the starting position
+func New(t teeko.ScoreTable, rng bot.Rand) *Engine {
+	return &Engine{Table: t, Rand: rng, board: teeko.Board{P: true}, difficulty: bot.Perfect}
+}
+
+var errQuit = errors.New("quit")
+
+// Run reads commands from in until quit or the end of input, writing answers to out
+func (e *Engine) Run(in io.Reader, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	sc := bufio.NewScanner(in)
+	for sc.Scan() {
+		err := e.Execute(sc.Text(), w)
+		if err == errQuit {
+			return w.Flush()
+		}
+		if err != nil {
+			fmt.Fprintf(w, "info string error: %v\n", err)
+		}
+		// GUIs wait for each answer before sending more
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Execute runs one command line
+func (e *Engine) Execute(line string, w io.Writer) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	switch cmd, args := fields[0], fields[1:]; cmd {
+	case "tep":
+		fmt.Fprintf(w, "id name %s\n", Name)
+		fmt.Fprintf(w, "option name difficulty type combo default %s", e.difficulty)
+		for _, d := range bot.Difficulties {
+			fmt.Fprintf(w, " var %s", d)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "tepok")
+	case "isready":
+		fmt.Fprintln(w, "readyok")
+	case "setoption":
+		return e.setOption(args)
+	case "newgame":
+		e.board, e.noPosition = teeko.Board{P: true}, false
+	case "position":
+		board, err := parsePosition(args)
+		if err != nil {
+			e.noPosition = true
+			return err
+		}
+		e.board, e.noPosition = board, false
+	case "go":
+		return e.goCmd(args, w)
+	case "stop":
+	case "quit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	return nil
+}
+
+// setoption name <name> value <value>, for the options listed by tep
+func (e *Engine) setOption(args []string) error {
+	if len(args) != 4 || args[0] != "name" || args[2] != "value" {
+		return errors.New("setoption needs name <option> value <value>")
+	}
+	switch name, value := args[1], args[3]; name {
+	case "difficulty":
+		d := bot.Difficulty(value)
+		if _, err := d.Config(); err != nil {
+			return err
+		}
+		e.difficulty = d
+	default:
+		return fmt.Errorf("unknown option %q", name)
+	}
+	return nil
+}
+
+func parsePosition(args []string) (teeko.Board, error) {
+	if len(args) == 0 {
+		return teeko.Board{}, errors.New("position needs startpos or a position string")
+	}
+	board := teeko.Board{P: true}
+	rest := args[1:]
+	if args[0] != "startpos" {
+		if len(args) < 3 {
+			return board, fmt.Errorf("position string %q needs the player to move and the phase", strings.Join(args, " "))
+		}
+		var err error
+		if board, err = teeko.ParsePosition(strings.Join(args[:3], " ")); err != nil {
+			return board, err
+		}
+		rest = args[3:]
+	}
+
+	if len(rest) == 0 {
+		return board, nil
+	}
+	if rest[0] != "moves" {
+		return board, fmt.Errorf("expected moves, not %q", rest[0])
+	}
+	for _, tok := range rest[1:] {
+		action, err := teeko.ParseAction(tok)
+		if err != nil {
+			return board, err
+		}
+		if board, err = board.Apply(action); err != nil {
+			return board, err
+		}
+	}
+	return board, nil
+}
+
+type goOptions struct {
+	difficulty bot.Difficulty
+	moveTime   time.Duration
+	depth      int
+}
+
+func parseGo(args []string, difficulty bot.Difficulty) (goOptions, error) {
+	opts := goOptions{difficulty: difficulty, moveTime: DefaultMoveTime, depth: DefaultDepth}
+	for i := 0; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return opts, fmt.Errorf("go %s needs a value", args[i])
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "difficulty":
+			opts.difficulty = bot.Difficulty(value)
+		case "movetime":
+			ms, err := strconv.Atoi(value)
+			if err != nil || ms <= 0 {
+				return opts, fmt.Errorf("bad movetime %q", value)
+			}
+			opts.moveTime = time.Duration(ms) * time.Millisecond
+		case "depth":
+			depth, err := strconv.Atoi(value)
+			if err != nil || depth <= 0 {
+				return opts, fmt.Errorf("bad depth %q", value)
+			}
+			opts.depth = depth
+		default:
+			return opts, fmt.Errorf("unknown go option %q", args[i])
+		}
+	}
+	return opts, nil
+}
+
+func (e *Engine) goCmd(args []string, w io.Writer) error {
+	if e.noPosition {
+		return errors.New("no position: the last position command failed")
+	}
+	opts, err := parseGo(args, e.difficulty)
+	if err != nil {
+		return err
+	}
+	cfg, err := opts.difficulty.Config()
+	if err != nil {
+		return err
+	}
+	if teeko.IsWin(e.board.A) || teeko.IsWin(e.board.B) {
+		fmt.Fprintln(w, "bestmove none")
+		return nil
+	}
+
+	var moves []teeko.Move
+	depth := 0
+	if e.Table != nil {
+		moves = teeko.GenerateMoves(e.Table, e.board)
+	} else {
+		moves, depth = Search(e.board, opts.depth, time.Now().Add(opts.moveTime))
+	}
+	if len(moves) == 0 {
+		fmt.Fprintln(w, "bestmove none")
+		return nil
+	}
+	best := moves[0]
+	for _, m := range moves[1:] {
+		if m.Score > best.Score {
+			best = m
+		}
+	}
+	chosen := bot.New(e.Table, cfg, e.Rand).Select(moves)
+	if chosen.Score == best.Score {
+		// Show the line actually played among equally good moves
+		best = chosen
+	}
+
+	fmt.Fprintf(w, "info depth %d score %d outcome %s", depth, best.Score, outcome(best.Score))
+	if d, ok := teeko.Distance(best.Score); ok {
+		fmt.Fprintf(w, " distance %d", d)
+	}
+	fmt.Fprintf(w, " pv %s\n", strings.Join(e.pv(best), " "))
+	fmt.Fprintf(w, "bestmove %v\n", chosen.Action)
+	return nil
+}
+
+// Best line starting with the best move; only the database can follow it further
+func (e *Engine) pv(best teeko.Move) []string {
+	line := []string{best.Action.String()}
+	if e.Table == nil {
+		return line
+	}
+	next, err := e.board.Apply(best.Action)
+	if err != nil {
+		return line
+	}
+	for _, p := range teeko.PrincipalVariation(e.Table, next, pvPlies) {
+		line = append(line, p.Action.String())
+	}
+	return line
+}
+
+func outcome(score int8) string {
+	switch {
+	case score > teeko.ScoreHeuristicMax:
+		return "win"
+	case score < -teeko.ScoreHeuristicMax:
+		return "loss"
+	}
+	return "draw"
+}
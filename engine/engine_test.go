@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+func run(t *testing.T, script string) []string {
+	t.Helper()
+	var out strings.Builder
+	e := New(nil, rand.New(rand.NewPCG(1, 2)))
+	if err := e.Run(strings.NewReader(script), &out); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+func TestImmediateWin(t *testing.T) {
+	lines := run(t, "position AAA../BB.../...../...../..... b drop moves c4 e1\ngo depth 2\n")
+	last := lines[len(lines)-1]
+	if !strings.HasPrefix(lines[0], "info depth") || !strings.Contains(lines[0], "outcome win distance 1") {
+		t.Errorf("info line %q, want a win in 1", lines[0])
+	}
+	if last != "bestmove d4" {
+		t.Errorf("got %q, want bestmove d4", last)
+	}
+}
+
+func TestFailedPositionClearsBoard(t *testing.T) {
+	lines := run(t, "position startpos moves c3\nposition startpos moves c3 c3\ngo depth 1\nposition startpos\ngo depth 1\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "info string error:") {
+		t.Errorf("illegal position answered %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "info string error: no position") {
+		t.Errorf("go after an illegal position answered %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "bestmove ") {
+		t.Errorf("go after a valid position answered %q", lines[3])
+	}
+}
+
+func TestSetOption(t *testing.T) {
+	lines := run(t, "setoption name difficulty value easy\ntep\nsetoption name difficulty value godlike\nsetoption name speed value 3\n")
+	if want := "option name difficulty type combo default easy"; !strings.HasPrefix(lines[1], want) {
+		t.Errorf("got %q, want prefix %q", lines[1], want)
+	}
+	errors := 0
+	for _, l := range lines {
+		if strings.HasPrefix(l, "info string error:") {
+			errors++
+		}
+	}
+	if errors != 2 {
+		t.Errorf("%d errors for 2 bad options: %q", errors, lines)
+	}
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"math/bits"
+	"time"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Deepest search; forced scores must stay clear of heuristic ones
+const MaxDepth = int(teeko.ScoreAWin-teeko.ScoreHeuristicMax) - 2
+
+// Nodes searched between checks of the deadline
+const checkEvery = 1 << 12
+
+// searcher scores moves without a database, by negamax with alpha-beta pruning.
+// Scores use the database's scale: a win completed k plies after the move
+// scores 127-k, and positions at the search horizon get a heuristic score.
+type searcher struct {
+	deadline time.Time
+	nodes    int
+	stopped  bool
+}
+
+// Search scores every action of the player to move, deepening one ply at a time
+// up to depth or until the deadline (none if zero). It returns the moves of the
+// deepest completed iteration in GenerateMoves order and that depth.
+func Search(board teeko.Board, depth int, deadline time.Time) ([]teeko.Move, int) {
+	s := &searcher{deadline: deadline}
+	mover, other := board.Mover()
+	var moves []teeko.Move
+	reached := 0
+	for d := 1; d <= min(depth, MaxDepth); d++ {
+		scored := s.root(mover, other, d)
+		if s.stopped {
+			break
+		}
+		moves, reached = scored, d
+		if decided(moves) {
+			break
+		}
+	}
+	if moves == nil {
+		// Not even one ply in time; every legal move is worth listing
+		moves = children(mover, other, func(sq, dest int, _, _ uint32) teeko.Move {
+			return teeko.Move{Action: action(sq, dest)}
+		})
+	}
+	return moves, reached
+}
+
+// Whether deeper searches cannot change the scores: every move is forced
+func decided(moves []teeko.Move) bool {
+	for _, m := range moves {
+		if m.Score >= -teeko.ScoreHeuristicMax && m.Score <= teeko.ScoreHeuristicMax {
+			return false
+		}
+	}
+	return true
+}
+
+// Scores every move with a full window, so that bots can compare them all
+func (s *searcher) root(mover, other uint32, depth int) []teeko.Move {
+	return children(mover, other, func(sq, dest int, nextMover, nextOther uint32) teeko.Move {
+		score := -s.negamax(nextMover, nextOther, 1, depth-1, -int(teeko.ScoreAWin)-1, int(teeko.ScoreAWin)+1)
+		return teeko.Move{Action: action(sq, dest), Score: int8(score)}
+	})
+}
+
+func (s *searcher) negamax(mover, other uint32, ply, depth, alpha, beta int) int {
+	if teeko.IsWin(other) {
+		return -(int(teeko.ScoreAWin) + 1 - ply)
+	}
+	if depth == 0 {
+		return evaluate(mover, other)
+	}
+	if s.nodes++; s.nodes%checkEvery == 0 && !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		s.stopped = true
+	}
+	if s.stopped {
+		return 0
+	}
+	best := -int(teeko.ScoreAWin) - 1
+	children(mover, other, func(_, _ int, nextMover, nextOther uint32) teeko.Move {
+		if alpha >= beta {
+			return teeko.Move{}
+		}
+		score := -s.negamax(nextMover, nextOther, ply+1, depth-1, -beta, -alpha)
+		best = max(best, score)
+		alpha = max(alpha, score)
+		return teeko.Move{}
+	})
+	return best
+}
+
+// Heuristic score at the horizon, as the solver's evaluation of drawn positions
+func evaluate(mover, other uint32) int {
+	score := teeko.Negamax(mover, other, 0, 0, 0)
+	return max(-int(teeko.ScoreHeuristicMax), min(int(teeko.ScoreHeuristicMax), score))
+}
+
+// Calls visit for every action of mover in GenerateMoves order with the position
+// it leads to, seen from the next player to move; sq is -1 for drops
+func children(mover, other uint32, visit func(sq, dest int, nextMover, nextOther uint32) teeko.Move) []teeko.Move {
+	var moves []teeko.Move
+	occupied := mover | other
+	if bits.OnesCount32(occupied) == 8 {
+		for sq := range teeko.Size {
+			if mover&(1<<sq) == 0 {
+				continue
+			}
+			for dest := range teeko.Size {
+				if teeko.Neighs[sq]&^occupied&(1<<dest) == 0 {
+					continue
+				}
+				moves = append(moves, visit(sq, dest, other, mover^(1<<sq)|(1<<dest)))
+			}
+		}
+		return moves
+	}
+	for sq := range teeko.Size {
+		if occupied&(1<<sq) == 0 {
+			moves = append(moves, visit(-1, sq, other, mover|(1<<sq)))
+		}
+	}
+	return moves
+}
+
+func action(sq, dest int) teeko.Action {
+	if sq < 0 {
+		return teeko.Drop(dest)
+	}
+	return teeko.Action{From: sq, To: dest}
+}
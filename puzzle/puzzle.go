@@ -0,0 +1,165 @@
+// Package puzzle finds positions of the solved database where a single move
+// keeps a forced win, for a feed of "find the winning move" puzzles
+package puzzle
+
+import (
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+
+	"github.com/pcarrier/teeko.cc/bot"
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Puzzle is a position where exactly one action wins by force
+type Puzzle struct {
+	Position string   `json:"position"` // position string, see teeko.FormatPosition
+	A        uint32   `json:"a"`
+	B        uint32   `json:"b"`
+	Turn     string   `json:"turn"` // player to move: a or b
+	WinIn    int      `json:"winIn"`
+	Solution []string `json:"solution"` // the forced line, winning action first
+	Draws    int      `json:"draws"`    // alternatives that only draw
+	Losses   int      `json:"losses"`   // alternatives that lose by force
+	// Share of attempts in which the bots below perfect miss the winning action,
+	// from 0 (all find it) to 1 (none do)
+	Difficulty float64 `json:"difficulty"`
+}
+
+// Options select the puzzles to find
+type Options struct {
+	Pieces  []int  // piece counts to scan, 8 for the move phase; all when empty
+	WinIn   int    // length of the win, as reported by teeko.PositionDistance; any when 0
+	Trials  int    // attempts per bot when estimating difficulty
+	Seed    uint64 // for the difficulty estimate
+	Workers int    // goroutines scanning the tables; runtime.NumCPU() when 0
+}
+
+// Attempts per bot when Options.Trials is 0
+const DefaultTrials = 64
+
+// Bots whose misses make a puzzle difficult
+var solvers = []bot.Difficulty{bot.Beginner, bot.Easy, bot.Medium, bot.Hard}
+
+// Find scans the canonical positions of db, so each puzzle appears once up to
+// symmetry, in order of piece count then rank. Scanning is much faster once
+// db.BuildIndex has been called.
+func Find(db *teeko.DB, opts Options) []Puzzle {
+	pieces := opts.Pieces
+	if len(pieces) == 0 {
+		pieces = []int{0, 1, 2, 3, 4, 5, 6, 7, 8}
+	}
+	if opts.Trials == 0 {
+		opts.Trials = DefaultTrials
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	var puzzles []Puzzle
+	for _, n := range pieces {
+		count := db.Counts[n]
+		chunkSize := (count + workers - 1) / workers
+		found := make([][]Puzzle, workers)
+		var wg sync.WaitGroup
+		for w := range workers {
+			startIdx := w * chunkSize
+			endIdx := min(startIdx+chunkSize, count)
+			wg.Add(1)
+			go func(startIdx, endIdx int) {
+				defer wg.Done()
+				for rank := startIdx; rank < endIdx; rank++ {
+					if p, ok := check(db, n, rank, opts); ok {
+						found[w] = append(found[w], p)
+					}
+				}
+			}(startIdx, endIdx)
+		}
+		wg.Wait()
+		for _, f := range found {
+			puzzles = append(puzzles, f...)
+		}
+	}
+	return puzzles
+}
+
+// Check one canonical position, filtering on its score before generating moves
+func check(db *teeko.DB, n, rank int, opts Options) (Puzzle, bool) {
+	score := db.Scores[n][rank]
+	if score <= teeko.ScoreHeuristicMax || score == teeko.ScoreAWin {
+		return Puzzle{}, false
+	}
+	winIn, _ := teeko.PositionDistance(score)
+	if opts.WinIn != 0 && winIn != opts.WinIn {
+		return Puzzle{}, false
+	}
+	g := db.Unrank(n, rank)
+	mover, other := teeko.Degoedel(g, n)
+	if teeko.IsWin(mover) || teeko.IsWin(other) {
+		return Puzzle{}, false
+	}
+	return solve(db, teeko.TableBoard(mover, other, n), winIn, opts, g)
+}
+
+// Make a puzzle of a board won in winIn moves, unless several actions keep the win;
+// g seeds the difficulty estimate
+func solve(t teeko.ScoreTable, board teeko.Board, winIn int, opts Options, g int) (Puzzle, bool) {
+	moves := teeko.GenerateMoves(t, board)
+	var key teeko.Move
+	found := false
+	p := Puzzle{WinIn: winIn}
+	for _, m := range moves {
+		switch {
+		case m.Score > teeko.ScoreHeuristicMax:
+			if found {
+				// A second winning move
+				return Puzzle{}, false
+			}
+			key, found = m, true
+		case m.Score < -teeko.ScoreHeuristicMax:
+			p.Losses++
+		default:
+			p.Draws++
+		}
+	}
+	if !found {
+		return Puzzle{}, false
+	}
+
+	p.Position = teeko.FormatPosition(board)
+	p.A, p.B = board.A, board.B
+	p.Turn = "a"
+	if len(board.M)%2 == 1 {
+		p.Turn = "b"
+	}
+	p.Solution = []string{key.Action.String()}
+	next, err := board.Apply(key.Action)
+	if err != nil {
+		panic(err)
+	}
+	for _, ply := range teeko.PrincipalVariation(t, next, 0) {
+		p.Solution = append(p.Solution, ply.Action.String())
+	}
+	p.Difficulty = difficulty(t, moves, key.Action, opts, g)
+	return p, true
+}
+
+// Share of bot attempts missing the key action, each position drawing from its
+// own generator so results do not depend on the number of workers
+func difficulty(t teeko.ScoreTable, moves []teeko.Move, key teeko.Action, opts Options, g int) float64 {
+	rng := rand.New(rand.NewPCG(opts.Seed, uint64(g)))
+	misses := 0
+	for _, d := range solvers {
+		cfg, _ := d.Config()
+		b := bot.New(t, cfg, rng)
+		for range opts.Trials {
+			if b.Select(moves).Action != key {
+				misses++
+			}
+		}
+	}
+	share := float64(misses) / float64(len(solvers)*opts.Trials)
+	return math.Round(share*100) / 100
+}
@@ -0,0 +1,183 @@
+package puzzle
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// A score table that only sees immediate wins, with some scores overridden
+type toyTable map[teeko.Position]int8
+
+func (t toyTable) Lookup(a, b uint32, n int) int8 {
+	if teeko.IsWin(b) {
+		return teeko.ScoreBWin
+	}
+	if s, ok := t[teeko.Position{A: a, B: b}]; ok {
+		return s
+	}
+	// The player to move wins next if one of their actions ends the game
+	for _, m := range teeko.GenerateMoves(endings{}, teeko.Board{A: a, B: b}) {
+		if m.Score == teeko.ScoreAWin {
+			return teeko.ScoreAWin - 1
+		}
+	}
+	return 0
+}
+
+// Scores only games already won
+type endings struct{}
+
+func (endings) Lookup(a, b uint32, n int) int8 {
+	if teeko.IsWin(b) {
+		return teeko.ScoreBWin
+	}
+	return 0
+}
+
+func squares(t *testing.T, names ...string) uint32 {
+	t.Helper()
+	var mask uint32
+	for _, name := range names {
+		sq, err := teeko.ParseSquare(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mask |= 1 << sq
+	}
+	return mask
+}
+
+func board(t *testing.T, pos string) teeko.Board {
+	t.Helper()
+	b, err := teeko.ParsePosition(pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestSolveWinInOne(t *testing.T) {
+	// A completes the top row at d5; a2 hands Red the win on the a file
+	b := board(t, "AAA../B..../B..../B..../..... a drop")
+	tt := toyTable{}
+	p, ok := solve(tt, b, 1, Options{Trials: 8}, 42)
+	if !ok {
+		t.Fatal("not a puzzle")
+	}
+	if !slices.Equal(p.Solution, []string{"d5"}) {
+		t.Errorf("solution %v, want [d5]", p.Solution)
+	}
+	if p.Position != teeko.FormatPosition(b) || p.Turn != "a" || p.WinIn != 1 {
+		t.Errorf("got %+v", p)
+	}
+	// a1 blocks Red too, every other drop lets Red win
+	if p.Draws != 1 || p.Losses != 17 {
+		t.Errorf("%d draws and %d losses, want 1 and 17", p.Draws, p.Losses)
+	}
+	if p.Difficulty < 0 || p.Difficulty > 1 {
+		t.Errorf("difficulty %v out of range", p.Difficulty)
+	}
+
+	// The estimate only depends on the seed and the position
+	again, _ := solve(tt, b, 1, Options{Trials: 8}, 42)
+	if again.Difficulty != p.Difficulty {
+		t.Errorf("difficulty %v then %v", p.Difficulty, again.Difficulty)
+	}
+}
+
+func TestSolveRejects(t *testing.T) {
+	// Both a5 and e5 complete the row
+	if p, ok := solve(toyTable{}, board(t, ".AAA./B..../B..../...../....B a drop"), 1, Options{Trials: 1}, 0); ok {
+		t.Errorf("two winning moves, got puzzle %v", p.Solution)
+	}
+	// No move wins, whatever the position's score says
+	if p, ok := solve(toyTable{}, board(t, "A..../B..../...../...../..... a drop"), 1, Options{Trials: 1}, 0); ok {
+		t.Errorf("no winning move, got puzzle %v", p.Solution)
+	}
+}
+
+func TestSolveLine(t *testing.T) {
+	// Moving e3 to e4 threatens e4-d5, which Red cannot stop; d4 would too,
+	// but the table says it only draws, leaving a single winning move
+	b := board(t, "AAA../...../....A/...../BB.BB a move")
+	a, r := b.A, b.B
+	tt := toyTable{
+		{A: r, B: a&^squares(t, "e3") | squares(t, "e4")}: teeko.ScoreBWin + 2,
+		{A: r, B: a&^squares(t, "e3") | squares(t, "d4")}: 0,
+	}
+	p, ok := solve(tt, b, 3, Options{Trials: 1}, 0)
+	if !ok {
+		t.Fatal("not a puzzle")
+	}
+	if len(p.Solution) != 3 || p.Solution[0] != "e3-e4" || p.Solution[2] != "e4-d5" {
+		t.Errorf("solution %v, want e3-e4, a Red move, e4-d5", p.Solution)
+	}
+}
+
+// A database whose tables up to 3 pieces are indexed, all drawn
+func smallDB() *teeko.DB {
+	db := &teeko.DB{}
+	for n := range 4 {
+		numBlocks := (teeko.Configs[n] + teeko.BLOCK_SIZE - 1) / teeko.BLOCK_SIZE
+		db.Checkpoints[n] = make([]int, numBlocks+1)
+		rank := 0
+		for g := range teeko.Configs[n] {
+			if g%teeko.BLOCK_SIZE == 0 {
+				db.Checkpoints[n][g/teeko.BLOCK_SIZE] = rank
+			}
+			if teeko.IsCanonical(g, n) {
+				rank++
+			}
+		}
+		db.Checkpoints[n][numBlocks] = rank
+		db.Counts[n] = rank
+		db.Scores[n] = make([]int8, rank)
+	}
+	return db
+}
+
+func TestFind(t *testing.T) {
+	db := smallDB()
+	// Blue to move wins by dropping on d2, as far as the tables say
+	mover, other := squares(t, "a5"), squares(t, "b3")
+	db.Store(mover, other, 2, teeko.ScoreAWin-1)
+	db.Store(other, mover|squares(t, "d2"), 3, teeko.ScoreBWin)
+	// A position claiming a win that no move delivers
+	db.Store(squares(t, "c3"), squares(t, "e1"), 2, teeko.ScoreAWin-1)
+
+	puzzles := Find(db, Options{Pieces: []int{2}, Trials: 4, Workers: 3})
+	if len(puzzles) != 1 {
+		t.Fatalf("found %d puzzles, want 1: %+v", len(puzzles), puzzles)
+	}
+	p := puzzles[0]
+	// Tables hold one position per symmetry class, maybe not this one
+	b := board(t, p.Position)
+	if teeko.Canonical(b.A, b.B, 2) != teeko.Canonical(mover, other, 2) {
+		t.Errorf("puzzle at %s, want a symmetry of a5 against b3", p.Position)
+	}
+	if p.WinIn != 1 || len(p.Solution) != 1 || p.Draws != 22 || p.Losses != 0 {
+		t.Errorf("got %+v", p)
+	}
+	next, err := b.Apply(mustAction(t, p.Solution[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := db.Lookup(next.B, next.A, 3); s != teeko.ScoreBWin {
+		t.Errorf("key move %s leads to a score of %d", p.Solution[0], s)
+	}
+
+	if found := Find(db, Options{Pieces: []int{2}, WinIn: 2, Trials: 1}); len(found) != 0 {
+		t.Errorf("found %d puzzles won in 2", len(found))
+	}
+}
+
+func mustAction(t *testing.T, s string) teeko.Action {
+	t.Helper()
+	a, err := teeko.ParseAction(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
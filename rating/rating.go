@@ -0,0 +1,235 @@
+// Package rating maintains Glicko-2 ratings of players and bots.
+// See Mark Glickman, "Example of the Glicko-2 system" (2013).
+package rating
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Defaults for new players and the system constant
+const (
+	DefaultRating     = 1500
+	DefaultRD         = 350
+	DefaultVolatility = 0.06
+	DefaultTau        = 0.5
+
+	// Ratings are provisional while their deviation stays above this
+	ProvisionalRD = 110
+
+	// Conversion between the Glicko and Glicko-2 scales
+	glicko2Scale = 173.7178
+	// Convergence tolerance of the volatility iteration
+	epsilon = 1e-6
+)
+
+// Player is one row of the ratings table
+type Player struct {
+	Name       string  `json:"name"`
+	Rating     float64 `json:"rating"`
+	RD         float64 `json:"rd"`
+	Volatility float64 `json:"volatility"`
+	Games      int     `json:"games"`
+	Wins       int     `json:"wins"`
+	Draws      int     `json:"draws"`
+	Losses     int     `json:"losses"`
+}
+
+// Provisional reports whether the rating is still too uncertain to rank
+func (p *Player) Provisional() bool {
+	return p.RD > ProvisionalRD
+}
+
+// Result is one game between two players, Score being A's points: 1, 0.5 or 0
+type Result struct {
+	A, B  string
+	Score float64
+}
+
+// Table holds every player's rating
+type Table struct {
+	Tau     float64   `json:"tau"`
+	Periods int       `json:"periods"` // rating periods applied so far
+	Players []*Player `json:"players"` // best first after each period
+}
+
+// NewTable returns an empty table
+func NewTable() *Table {
+	return &Table{Tau: DefaultTau}
+}
+
+// Player returns a player, adding them with default ratings if unknown
+func (t *Table) Player(name string) *Player {
+	for _, p := range t.Players {
+		if p.Name == name {
+			return p
+		}
+	}
+	p := &Player{Name: name, Rating: DefaultRating, RD: DefaultRD, Volatility: DefaultVolatility}
+	t.Players = append(t.Players, p)
+	return p
+}
+
+type opponent struct {
+	mu, phi, score float64
+}
+
+// RatePeriod updates every rating with the games of one rating period, all rated
+// against the ratings from before the period. Players without games only become
+// more uncertain.
+func (t *Table) RatePeriod(results []Result) {
+	games := make(map[string][]opponent)
+	for _, r := range results {
+		a, b := t.Player(r.A), t.Player(r.B)
+		games[a.Name] = append(games[a.Name], opponent{toMu(b.Rating), b.RD / glicko2Scale, r.Score})
+		games[b.Name] = append(games[b.Name], opponent{toMu(a.Rating), a.RD / glicko2Scale, 1 - r.Score})
+		for _, side := range []struct {
+			p     *Player
+			score float64
+		}{{a, r.Score}, {b, 1 - r.Score}} {
+			side.p.Games++
+			switch side.score {
+			case 1:
+				side.p.Wins++
+			case 0:
+				side.p.Losses++
+			default:
+				side.p.Draws++
+			}
+		}
+	}
+
+	// Update from a snapshot so the order of players does not matter
+	type update struct{ rating, rd, volatility float64 }
+	updates := make([]update, len(t.Players))
+	for i, p := range t.Players {
+		r, rd, vol := t.rate(p, games[p.Name])
+		updates[i] = update{r, rd, vol}
+	}
+	for i, p := range t.Players {
+		p.Rating, p.RD, p.Volatility = updates[i].rating, updates[i].rd, updates[i].volatility
+	}
+	t.Periods++
+	slices.SortStableFunc(t.Players, func(x, y *Player) int {
+		switch {
+		case x.Rating > y.Rating:
+			return -1
+		case x.Rating < y.Rating:
+			return 1
+		}
+		return 0
+	})
+}
+
+func toMu(rating float64) float64 {
+	return (rating - DefaultRating) / glicko2Scale
+}
+
+func g(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu, muJ, phiJ float64) float64 {
+	return 1 / (1 + math.Exp(-g(phiJ)*(mu-muJ)))
+}
+
+// The Glicko-2 update of one player, steps 2 to 8 of Glickman's example
+func (t *Table) rate(p *Player, games []opponent) (rating, rd, volatility float64) {
+	mu, phi, sigma := toMu(p.Rating), p.RD/glicko2Scale, p.Volatility
+	if len(games) == 0 {
+		phi = math.Sqrt(phi*phi + sigma*sigma)
+		return p.Rating, math.Min(phi*glicko2Scale, DefaultRD), sigma
+	}
+
+	var invV, sum float64
+	for _, o := range games {
+		gj := g(o.phi)
+		e := expected(mu, o.mu, o.phi)
+		invV += gj * gj * e * (1 - e)
+		sum += gj * (o.score - e)
+	}
+	v := 1 / invV
+	delta := v * sum
+
+	sigma = t.volatility(phi, sigma, v, delta)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	mu += phi * phi * sum
+	return mu*glicko2Scale + DefaultRating, phi * glicko2Scale, sigma
+}
+
+// New volatility by the Illinois algorithm (step 5)
+func (t *Table) volatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-phi*phi-v-ex)/(2*d*d) - (x-a)/(t.Tau*t.Tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*t.Tau) < 0 {
+			k++
+		}
+		B = a - k*t.Tau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > epsilon {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
+
+// Load reads a table saved by Save, or returns an empty one if the file does not exist
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewTable(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := NewTable()
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Save writes the table atomically, so an interrupted save keeps the previous one
+func (t *Table) Save(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
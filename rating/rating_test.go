@@ -0,0 +1,57 @@
+package rating
+
+import (
+	"math"
+	"testing"
+)
+
+func near(got, want, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+// Glickman's worked example, "Example of the Glicko-2 system"
+func TestGlickmanExample(t *testing.T) {
+	table := NewTable()
+	table.Players = []*Player{
+		{Name: "p", Rating: 1500, RD: 200, Volatility: 0.06},
+		{Name: "o1", Rating: 1400, RD: 30, Volatility: 0.06},
+		{Name: "o2", Rating: 1550, RD: 100, Volatility: 0.06},
+		{Name: "o3", Rating: 1700, RD: 300, Volatility: 0.06},
+	}
+	p := table.Players[0]
+	table.RatePeriod([]Result{
+		{A: "p", B: "o1", Score: 1},
+		{A: "o2", B: "p", Score: 1},
+		{A: "p", B: "o3", Score: 0},
+	})
+	if !near(p.Rating, 1464.06, 0.01) || !near(p.RD, 151.52, 0.01) || !near(p.Volatility, 0.05999, 0.00001) {
+		t.Errorf("got %.2f/%.2f/%.5f, want 1464.06/151.52/0.05999", p.Rating, p.RD, p.Volatility)
+	}
+	if p.Games != 3 || p.Wins != 1 || p.Losses != 2 || p.Draws != 0 {
+		t.Errorf("%d games, %d wins, %d draws, %d losses", p.Games, p.Wins, p.Draws, p.Losses)
+	}
+	if table.Periods != 1 {
+		t.Errorf("%d periods, want 1", table.Periods)
+	}
+}
+
+func TestIdlePlayer(t *testing.T) {
+	table := NewTable()
+	idle := table.Player("idle")
+	idle.RD = 200
+	table.RatePeriod([]Result{{A: "x", B: "y", Score: 0.5}})
+	// phi* = sqrt(phi² + sigma²), back on the Glicko scale
+	want := math.Sqrt(200*200 + 0.06*0.06*glicko2Scale*glicko2Scale)
+	if idle.Rating != DefaultRating || !near(idle.RD, want, 1e-9) || idle.Volatility != DefaultVolatility {
+		t.Errorf("got %v/%v/%v, want %v/%.2f/%v", idle.Rating, idle.RD, idle.Volatility, DefaultRating, want, DefaultVolatility)
+	}
+	if idle.Games != 0 {
+		t.Errorf("%d games, want 0", idle.Games)
+	}
+
+	// Deviations never grow past that of a new player
+	table.RatePeriod(nil)
+	if fresh := table.Player("x"); fresh.RD > DefaultRD {
+		t.Errorf("RD %v above %v", fresh.RD, DefaultRD)
+	}
+}
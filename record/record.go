@@ -0,0 +1,364 @@
+// Package record reads and writes games in a PGN-like text format:
+//
+//	[Blue "alice"]
+//	[Red "bob"]
+//	[Date "2026.10.17"]
+//	[Variant "Teeko"]
+//	[Result "0-1"]
+//
+//	1. c3 c4 2. b3 d4 3. b4 b2 4. d2 a5 5. d2-c2 c4-d3 6. c2-c1 d3-c4 {Blue resigns} 0-1
+//
+// Headers are optional and free-form. Moves use the notation of the teeko package
+// and may be preceded by move numbers; comments run from ; to the end of the line
+// or between braces. A file may hold several games separated by blank lines.
+package record
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Standard header names, written first and in this order
+const (
+	TagBlue    = "Blue" // player A, who moves first
+	TagRed     = "Red"  // player B
+	TagDate    = "Date"
+	TagVariant = "Variant"
+	TagResult  = "Result"
+)
+
+var standardTags = []string{TagBlue, TagRed, TagDate, TagVariant, TagResult}
+
+// Results, as in PGN
+const (
+	BlueWins   = "1-0"
+	RedWins    = "0-1"
+	Draw       = "1/2-1/2"
+	Unfinished = "*"
+)
+
+// The only variant the rules implement
+const Variant = "Teeko"
+
+var (
+	ErrVariant = errors.New("unsupported variant")
+	ErrResult  = errors.New("result does not match the final position")
+)
+
+// Tag is a header line
+type Tag struct {
+	Name, Value string
+}
+
+// Record is one game
+type Record struct {
+	Tags    []Tag // in file order
+	Actions []teeko.Action
+}
+
+// Get returns the value of a header, or "" when absent
+func (r *Record) Get(name string) string {
+	for _, t := range r.Tags {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// Set replaces a header, or adds it
+func (r *Record) Set(name, value string) {
+	for i, t := range r.Tags {
+		if t.Name == name {
+			r.Tags[i].Value = value
+			return
+		}
+	}
+	r.Tags = append(r.Tags, Tag{name, value})
+}
+
+// Board replays the game, checking every action against the rules
+func (r *Record) Board() (teeko.Board, error) {
+	return teeko.Replay(r.Actions)
+}
+
+// Validate replays the game and checks the headers agree with it.
+// A game without a win on the board may still have been decided by resignation,
+// and a game without a Result header may have any result.
+func (r *Record) Validate() error {
+	if v := r.Get(TagVariant); v != "" && v != Variant {
+		return fmt.Errorf("%w %q", ErrVariant, v)
+	}
+	board, err := r.Board()
+	if err != nil {
+		return err
+	}
+	switch result := r.Get(TagResult); {
+	case result == "":
+		// Any result is consistent with a missing header
+	case teeko.IsWin(board.A) && result != BlueWins,
+		teeko.IsWin(board.B) && result != RedWins:
+		return fmt.Errorf("%w: %q", ErrResult, result)
+	case result != BlueWins && result != RedWins && result != Draw && result != Unfinished:
+		return fmt.Errorf("%w: %q", ErrResult, result)
+	}
+	return nil
+}
+
+// FromBoard makes a record of a board from model.ts, checking its pieces match its history
+func FromBoard(board teeko.Board) (*Record, error) {
+	if err := teeko.CheckHistory(teeko.Board{}, board); err != nil {
+		return nil, err
+	}
+	r := &Record{Actions: board.M}
+	r.Set(TagVariant, Variant)
+	r.Set(TagResult, ResultOf(board))
+	return r, nil
+}
+
+// ResultOf returns the result shown on a board
+func ResultOf(board teeko.Board) string {
+	switch {
+	case teeko.IsWin(board.A):
+		return BlueWins
+	case teeko.IsWin(board.B):
+		return RedWins
+	}
+	return Unfinished
+}
+
+// Reader reads records one after another
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a reader of the records in r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// SyntaxError locates a malformed record
+type SyntaxError struct {
+	Line int
+	Err  error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+func (rd *Reader) fail(format string, args ...any) error {
+	return &SyntaxError{rd.line, fmt.Errorf(format, args...)}
+}
+
+// Read returns the next record, or io.EOF when there are no more
+func (rd *Reader) Read() (*Record, error) {
+	var rec *Record
+	inMoves := false
+	inComment := false
+	ended := false // the result token was seen
+
+	for !ended {
+		line, err := rd.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF && rec != nil {
+				break
+			}
+			return nil, err
+		}
+		rd.line++
+		text := strings.TrimSpace(line)
+
+		if !inComment && strings.HasPrefix(text, "[") {
+			if inMoves {
+				return nil, rd.fail("header after moves")
+			}
+			tag, err := parseTag(text)
+			if err != nil {
+				return nil, rd.fail("%v", err)
+			}
+			if rec == nil {
+				rec = &Record{}
+			}
+			rec.Tags = append(rec.Tags, tag)
+			continue
+		}
+		if text == "" {
+			if rec != nil && inMoves && !inComment {
+				break
+			}
+			continue
+		}
+
+		if rec == nil {
+			rec = &Record{}
+		}
+		inMoves = true
+		tokens, stillInComment := tokenize(text, inComment)
+		inComment = stillInComment
+		for _, tok := range tokens {
+			switch {
+			case ended:
+				return nil, rd.fail("%q after the result", tok)
+			case tok == BlueWins || tok == RedWins || tok == Draw || tok == Unfinished:
+				if result := rec.Get(TagResult); result != "" && result != tok {
+					return nil, rd.fail("result %s differs from header %s", tok, result)
+				}
+				rec.Set(TagResult, tok)
+				ended = true
+			case isMoveNumber(tok):
+			default:
+				a, err := teeko.ParseAction(tok)
+				if err != nil {
+					return nil, rd.fail("%v", err)
+				}
+				rec.Actions = append(rec.Actions, a)
+			}
+		}
+	}
+	if inComment {
+		return nil, rd.fail("unterminated comment")
+	}
+	return rec, nil
+}
+
+// ReadAll returns every record in r
+func ReadAll(r io.Reader) ([]*Record, error) {
+	rd := NewReader(r)
+	var records []*Record
+	for {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+func parseTag(text string) (Tag, error) {
+	if !strings.HasSuffix(text, "]") {
+		return Tag{}, errors.New("header must end with ]")
+	}
+	name, quoted, ok := strings.Cut(strings.TrimSpace(text[1:len(text)-1]), " ")
+	if !ok || name == "" {
+		return Tag{}, errors.New(`header must look like [Name "value"]`)
+	}
+	value, err := strconv.Unquote(strings.TrimSpace(quoted))
+	if err != nil {
+		return Tag{}, fmt.Errorf("header %s: value must be a quoted string", name)
+	}
+	return Tag{name, value}, nil
+}
+
+// Split a line of movetext into tokens, dropping comments
+func tokenize(text string, inComment bool) (tokens []string, stillInComment bool) {
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			tokens = append(tokens, b.String())
+			b.Reset()
+		}
+	}
+	for _, c := range text {
+		switch {
+		case inComment:
+			inComment = c != '}'
+		case c == '{':
+			flush()
+			inComment = true
+		case c == ';':
+			flush()
+			return tokens, false
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		default:
+			b.WriteRune(c)
+		}
+	}
+	flush()
+	return tokens, inComment
+}
+
+// Move numbers such as 12. (and 12... before a Red move) are only decoration
+func isMoveNumber(tok string) bool {
+	digits := strings.TrimRight(tok, ".")
+	if digits == tok || digits == "" {
+		return false
+	}
+	_, err := strconv.Atoi(digits)
+	return err == nil
+}
+
+// Longest movetext line written
+const lineWidth = 80
+
+// Write writes a record, standard headers first
+func Write(w io.Writer, r *Record) error {
+	bw := bufio.NewWriter(w)
+	for _, name := range standardTags {
+		if v := r.Get(name); v != "" {
+			fmt.Fprintf(bw, "[%s %s]\n", name, strconv.Quote(v))
+		}
+	}
+	for _, t := range r.Tags {
+		if !isStandard(t.Name) {
+			fmt.Fprintf(bw, "[%s %s]\n", t.Name, strconv.Quote(t.Value))
+		}
+	}
+	if len(r.Tags) > 0 {
+		bw.WriteString("\n")
+	}
+
+	var tokens []string
+	for i, a := range r.Actions {
+		if i%2 == 0 {
+			tokens = append(tokens, strconv.Itoa(i/2+1)+".")
+		}
+		tokens = append(tokens, a.String())
+	}
+	result := r.Get(TagResult)
+	if result == "" {
+		result = Unfinished
+	}
+	tokens = append(tokens, result)
+
+	width := 0
+	for i, tok := range tokens {
+		if i > 0 {
+			if width+1+len(tok) > lineWidth {
+				bw.WriteString("\n")
+				width = 0
+			} else {
+				bw.WriteString(" ")
+				width++
+			}
+		}
+		bw.WriteString(tok)
+		width += len(tok)
+	}
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+func isStandard(name string) bool {
+	for _, s := range standardTags {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package record
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+const games = `[Blue "alice"]
+[Red "bob"]
+[Event "club night"]
+[Result "0-1"]
+
+1. c3 c4 {solid} 2. b3 d4 3. b4 b2 4. d2 a5 ; both sides are down
+5. d2-c2 c4-d3 6. c2-c1 d3-c4
+{Blue resigns,
+a move too late} 0-1
+
+1. c3 1... d3 2. c4 d4 3. c5 d5 4. c2 1-0
+
+c3 c4 *
+`
+
+func actions(t *testing.T, s string) []teeko.Action {
+	t.Helper()
+	a, err := teeko.ParseActions(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestRead(t *testing.T) {
+	records, err := ReadAll(strings.NewReader(games))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		moves  string
+		result string
+		tags   int
+	}{
+		{"c3 c4 b3 d4 b4 b2 d2 a5 d2-c2 c4-d3 c2-c1 d3-c4", RedWins, 4},
+		{"c3 d3 c4 d4 c5 d5 c2", BlueWins, 1},
+		{"c3 c4", Unfinished, 1},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("read %d games, want %d", len(records), len(want))
+	}
+	for i, w := range want {
+		rec := records[i]
+		if !slices.Equal(rec.Actions, actions(t, w.moves)) {
+			t.Errorf("game %d: actions %v, want %s", i+1, teeko.FormatActions(rec.Actions), w.moves)
+		}
+		if got := rec.Get(TagResult); got != w.result {
+			t.Errorf("game %d: result %q, want %q", i+1, got, w.result)
+		}
+		if len(rec.Tags) != w.tags {
+			t.Errorf("game %d: tags %v, want %d", i+1, rec.Tags, w.tags)
+		}
+		if err := rec.Validate(); err != nil {
+			t.Errorf("game %d: %v", i+1, err)
+		}
+	}
+	if got := records[0].Get("Event"); got != "club night" {
+		t.Errorf("Event %q, want club night", got)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	records, err := ReadAll(strings.NewReader(games))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A long game, wrapped over several lines
+	long := &Record{Actions: actions(t, "c3 c4 b3 d4 b4 b2 d2 a5")}
+	for range 12 {
+		long.Actions = append(long.Actions, actions(t, "d2-e1 a5-a4 e1-d2 a4-a5")...)
+	}
+	records = append(records, long)
+
+	var buf bytes.Buffer
+	for i, rec := range records {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if err := Write(&buf, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	text := buf.String()
+	for _, line := range strings.Split(text, "\n") {
+		if len(line) > lineWidth {
+			t.Errorf("line of %d bytes: %s", len(line), line)
+		}
+	}
+	if !strings.HasPrefix(text, "[Blue \"alice\"]\n[Red \"bob\"]\n[Result \"0-1\"]\n[Event \"club night\"]\n\n1. c3 c4 2. b3") {
+		t.Errorf("standard headers first, then moves with numbers; got\n%s", text)
+	}
+
+	back, err := ReadAll(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != len(records) {
+		t.Fatalf("read back %d games, want %d", len(back), len(records))
+	}
+	for i, rec := range records {
+		if !slices.Equal(back[i].Actions, rec.Actions) {
+			t.Errorf("game %d: actions %v, want %v", i+1, teeko.FormatActions(back[i].Actions), teeko.FormatActions(rec.Actions))
+		}
+		for _, tag := range rec.Tags {
+			if got := back[i].Get(tag.Name); got != tag.Value {
+				t.Errorf("game %d: %s %q, want %q", i+1, tag.Name, got, tag.Value)
+			}
+		}
+	}
+	if got := back[3].Get(TagResult); got != Unfinished {
+		t.Errorf("game without a result written as %q, want %q", got, Unfinished)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, s := range []string{
+		"1. c3 c3x *",
+		"1. c3 c4 1-0 d3",
+		"[Result \"1-0\"]\n\n1. c3 0-1",
+		"1. c3 {unterminated\n",
+		"1. c3\n[Blue \"late\"]\n",
+		"[Blue alice]\n\n1. c3 *",
+	} {
+		var se *SyntaxError
+		if _, err := ReadAll(strings.NewReader(s)); !errors.As(err, &se) {
+			t.Errorf("%q: error %v, want a SyntaxError", s, err)
+		}
+	}
+}
+
+func TestValidateResult(t *testing.T) {
+	won := actions(t, "c3 d3 c4 d4 c5 d5 c2")
+	for _, tc := range []struct {
+		result  string
+		actions []teeko.Action
+		err     error
+	}{
+		{"", won, nil},
+		{"", won[:3], nil},
+		{BlueWins, won, nil},
+		{RedWins, won, ErrResult},
+		{Draw, won, ErrResult},
+		{Draw, won[:3], nil},
+		{RedWins, won[:3], nil}, // resignation
+		{"2-0", won[:3], ErrResult},
+	} {
+		rec := &Record{Actions: tc.actions}
+		if tc.result != "" {
+			rec.Set(TagResult, tc.result)
+		}
+		if err := rec.Validate(); !errors.Is(err, tc.err) {
+			t.Errorf("result %q after %d actions: %v, want %v", tc.result, len(tc.actions), err, tc.err)
+		}
+	}
+}
+
+func TestFromBoard(t *testing.T) {
+	board, err := teeko.Replay(actions(t, "c3 d3 c4 d4 c5 d5 c2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := FromBoard(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Get(TagResult) != BlueWins || !slices.Equal(rec.Actions, board.M) {
+		t.Errorf("got %v %s", teeko.FormatActions(rec.Actions), rec.Get(TagResult))
+	}
+	board.B |= 1 << 24
+	if _, err := FromBoard(board); !errors.Is(err, teeko.ErrMismatch) {
+		t.Errorf("pieces not matching the history: %v", err)
+	}
+}
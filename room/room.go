@@ -0,0 +1,550 @@
+// Package room serves multiplayer game rooms and the matchmaking lobby over WebSockets,
+// speaking the protocol of common/src/model.ts like ws/index.ts
+package room
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// How long an empty room keeps its game before being forgotten
+const roomLinger = time.Hour
+
+// Outgoing messages queued per client before it is considered too slow and dropped
+const sendQueue = 64
+
+// State mirrors State from model.ts
+type State struct {
+	Board    *teeko.Board `json:"board,omitempty"`
+	Analyzed bool         `json:"analyzed,omitempty"` // true if player used analysis
+}
+
+// RTCSignal mirrors RTCSignal from model.ts; the payload is relayed untouched
+type RTCSignal struct {
+	Type    string          `json:"type"` // "offer", "answer" or "ice-candidate"
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// RoomMessage mirrors RoomMessage from model.ts
+type RoomMessage struct {
+	St         *State     `json:"st,omitempty"`
+	RTC        *RTCSignal `json:"rtc,omitempty"`
+	Peers      []string   `json:"peers,omitempty"`
+	Voice      *bool      `json:"voice,omitempty"`
+	VoicePeers []string   `json:"voicePeers,omitempty"`
+}
+
+// Server holds every room and the lobby. A single lock serializes all events,
+// as the single-threaded Bun server did.
+type Server struct {
+	notify func(string)
+
+	mu       sync.Mutex
+	rooms    map[string]*room
+	waiting  *waiting
+	sessions int
+}
+
+type client struct {
+	socket    *socket
+	sessionID string
+	pill      string // "" when the client did not send one
+}
+
+type room struct {
+	path       string
+	clients    []*client // in order of arrival
+	voicePeers []string  // in order of joining voice chat
+	p1, p2     string
+	state      *State
+	analyzed   bool
+	timeout    *time.Timer
+}
+
+// Players with open lobby connections, all from the same pill
+type waiting struct {
+	pill    string
+	sockets []*socket
+}
+
+// NewServer returns a server reporting lobby activity through notify
+func NewServer(notify func(string)) *Server {
+	return &Server{notify: notify, rooms: make(map[string]*room)}
+}
+
+// Discord returns a notifier posting to a Discord webhook, or only logging without one
+func Discord(webhook string) func(string) {
+	if webhook == "" {
+		return func(content string) { log.Printf("Discord (mock): %s", content) }
+	}
+	return func(content string) {
+		go func() {
+			body, _ := json.Marshal(map[string]string{"content": content})
+			resp, err := http.Post(webhook, "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Printf("Discord error %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+}
+
+// ServeHTTP upgrades /room/<path> and /lobby requests, identifying players by ?pill=
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	pill := r.URL.Query().Get("pill")
+	switch path := r.URL.Path; {
+	case strings.HasPrefix(path, "/room/"):
+		s.serveRoom(w, r, pill, path[len("/room/"):])
+	case path == "/lobby":
+		s.serveLobby(w, r, pill)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveRoom(w http.ResponseWriter, r *http.Request, pill, roomPath string) {
+	ws, err := upgrade(w, r)
+	if err != nil {
+		log.Printf("Upgrade failed: %v", err)
+		return
+	}
+	sock := newSocket(ws)
+
+	s.mu.Lock()
+	s.sessions++
+	sessionID := fmt.Sprintf("s%d-%d", time.Now().UnixMilli(), s.sessions)
+	s.connectedToRoom(pill, sock, roomPath, sessionID)
+	s.mu.Unlock()
+
+	for {
+		data, err := ws.ReadMessage()
+		if err != nil {
+			logReadError(err)
+			break
+		}
+		s.mu.Lock()
+		s.roomMessage(sessionID, roomPath, data)
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	s.closeInRoom(sessionID, roomPath)
+	s.mu.Unlock()
+	sock.close()
+}
+
+func (s *Server) serveLobby(w http.ResponseWriter, r *http.Request, pill string) {
+	ws, err := upgrade(w, r)
+	if err != nil {
+		log.Printf("Upgrade failed: %v", err)
+		return
+	}
+	sock := newSocket(ws)
+
+	s.mu.Lock()
+	s.connectedToLobby(pill, sock)
+	s.mu.Unlock()
+
+	// Lobby clients only listen; reading notices when they leave
+	for {
+		if _, err := ws.ReadMessage(); err != nil {
+			logReadError(err)
+			break
+		}
+	}
+
+	s.mu.Lock()
+	s.closeInLobby(pill, sock)
+	s.mu.Unlock()
+	sock.close()
+}
+
+func logReadError(err error) {
+	if !errors.Is(err, errClosed) && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, net.ErrClosed) {
+		log.Printf("WS error %v", err)
+	}
+}
+
+func (s *Server) getRoom(path string) *room {
+	r := s.rooms[path]
+	if r == nil {
+		r = &room{path: path}
+		s.rooms[path] = r
+		log.Printf("Opened room %s", path)
+	}
+	return r
+}
+
+func (r *room) client(sessionID string) *client {
+	for _, c := range r.clients {
+		if c.sessionID == sessionID {
+			return c
+		}
+	}
+	return nil
+}
+
+// Pills of everyone in the room but the given session, without duplicates
+func (r *room) peers(excludeSessionID string) []string {
+	peers := []string{}
+	for _, c := range r.clients {
+		if c.pill != "" && c.sessionID != excludeSessionID && !slices.Contains(peers, c.pill) {
+			peers = append(peers, c.pill)
+		}
+	}
+	return peers
+}
+
+func (r *room) voicePeersFor(pill string) []string {
+	peers := []string{}
+	for _, p := range r.voicePeers {
+		if p != pill {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
+
+type popMessage struct {
+	Peers      []string `json:"peers"`
+	VoicePeers []string `json:"voicePeers"`
+}
+
+type voicePeersMessage struct {
+	VoicePeers []string `json:"voicePeers"`
+}
+
+// The st field is null when the room has nothing to share yet
+type stateMessage struct {
+	St *State `json:"st"`
+}
+
+type joinMessage struct {
+	Join string `json:"join"`
+}
+
+func (r *room) sendPop() {
+	for _, c := range r.clients {
+		c.socket.send(popMessage{r.peers(c.sessionID), r.voicePeersFor(c.pill)})
+	}
+}
+
+func (r *room) broadcastVoicePeers() {
+	for _, c := range r.clients {
+		c.socket.send(voicePeersMessage{r.voicePeersFor(c.pill)})
+	}
+}
+
+// Whether pill may act on the current board: anyone until P1 is known,
+// anyone but P1 until P2 is known, then only the player whose turn it is
+func (r *room) canPlay(pill string) bool {
+	if r.state == nil || r.state.Board == nil || r.p1 == "" {
+		return true
+	}
+	if r.p2 == "" {
+		return r.p1 != pill
+	}
+	if len(r.state.Board.M)%2 == 0 {
+		return r.p1 == pill
+	}
+	return r.p2 == pill
+}
+
+func (r *room) stateFor(pill string) *State {
+	if r.state == nil || r.state.Board == nil {
+		if r.analyzed {
+			return &State{Analyzed: true}
+		}
+		return nil
+	}
+	board := *r.state.Board
+	board.P = r.canPlay(pill)
+	return &State{Board: &board, Analyzed: r.analyzed}
+}
+
+func (r *room) sendState(c *client) {
+	c.socket.send(stateMessage{r.stateFor(c.pill)})
+}
+
+func (r *room) broadcastAnalyzed() {
+	for _, c := range r.clients {
+		c.socket.send(stateMessage{&State{Analyzed: true}})
+	}
+}
+
+// Accept a new state from a client, assigning seats as players first act,
+// or send the client the current state back if it may not make that change
+func (r *room) attemptAction(state *State, from *client) {
+	if state.Analyzed && !r.analyzed {
+		r.analyzed = true
+		r.broadcastAnalyzed()
+	}
+	if state.Board == nil {
+		return
+	}
+
+	pill := from.pill
+	actions := len(state.Board.M)
+	var known int
+	var prev teeko.Board
+	hasBoard := r.state != nil && r.state.Board != nil
+	if hasBoard {
+		prev = *r.state.Board
+		known = len(prev.M)
+		if actions != 0 && actions != known+1 && actions != known-1 {
+			log.Printf("Dropped moving from %d to %d actions", known, actions)
+			r.sendState(from)
+			return
+		}
+	}
+	// Without an accepted board, the whole history is replayed from the start
+	if err := teeko.CheckHistory(prev, *state.Board); err != nil {
+		log.Printf("Rejected board from %s: %v", pill, err)
+		r.sendState(from)
+		return
+	}
+
+	if actions == 0 {
+		r.p1, r.p2 = "", ""
+		r.analyzed = false // Reset on game restart
+	} else {
+		p1Playing := actions%2 == 1
+		current := r.p2
+		if p1Playing {
+			current = r.p1
+		}
+		switch {
+		case current == "" && p1Playing:
+			if r.p2 == pill {
+				log.Printf("%s already took P2", pill)
+				r.sendState(from)
+				return
+			}
+			r.p1 = pill
+		case current == "":
+			if r.p1 == pill {
+				log.Printf("%s already took P1", pill)
+				r.sendState(from)
+				return
+			}
+			r.p2 = pill
+		case (!hasBoard || actions != known-1) && pill != current:
+			// Undoing is open to both players
+			log.Printf("Blocking action from %s, not current player %s", pill, current)
+			r.sendState(from)
+			return
+		}
+	}
+
+	r.state = &State{Board: state.Board}
+	for _, c := range r.clients {
+		if c != from {
+			r.sendState(c)
+		}
+	}
+}
+
+func (r *room) relayRTC(signal RTCSignal) {
+	for _, c := range r.clients {
+		if c.pill == signal.To {
+			c.socket.send(RoomMessage{RTC: &signal})
+		}
+	}
+}
+
+func (s *Server) connectedToRoom(pill string, sock *socket, roomPath, sessionID string) {
+	log.Printf("%s (%s) connected to %s", pill, sessionID, roomPath)
+	r := s.getRoom(roomPath)
+	if r.timeout != nil {
+		r.timeout.Stop()
+		r.timeout = nil
+	}
+
+	c := &client{socket: sock, sessionID: sessionID, pill: pill}
+	r.clients = append(r.clients, c)
+
+	sock.send(popMessage{r.peers(sessionID), r.voicePeersFor(pill)})
+	r.sendPop()
+	r.sendState(c)
+}
+
+func (s *Server) roomMessage(sessionID, roomPath string, data []byte) {
+	r := s.getRoom(roomPath)
+	c := r.client(sessionID)
+	if c == nil {
+		return
+	}
+
+	var msg RoomMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log.Printf("roomMessage error %v", err)
+		return
+	}
+	if msg.St != nil {
+		r.attemptAction(msg.St, c)
+	}
+	if msg.RTC != nil && c.pill != "" {
+		signal := *msg.RTC
+		signal.From = c.pill
+		r.relayRTC(signal)
+	}
+	if msg.Voice != nil && c.pill != "" {
+		if *msg.Voice {
+			if !slices.Contains(r.voicePeers, c.pill) {
+				r.voicePeers = append(r.voicePeers, c.pill)
+			}
+		} else {
+			r.voicePeers = slices.DeleteFunc(r.voicePeers, func(p string) bool { return p == c.pill })
+		}
+		r.broadcastVoicePeers()
+	}
+}
+
+func (s *Server) closeInRoom(sessionID, roomPath string) {
+	r := s.getRoom(roomPath)
+	c := r.client(sessionID)
+	if c == nil {
+		return
+	}
+
+	log.Printf("%s (%s) left %s", c.pill, sessionID, roomPath)
+	r.clients = slices.DeleteFunc(r.clients, func(o *client) bool { return o == c })
+
+	// Leave voice chat unless the pill still has another session here
+	stillConnected := slices.ContainsFunc(r.clients, func(o *client) bool { return o.pill == c.pill })
+	if !stillConnected && c.pill != "" {
+		r.voicePeers = slices.DeleteFunc(r.voicePeers, func(p string) bool { return p == c.pill })
+	}
+
+	if len(r.clients) == 0 {
+		r.timeout = time.AfterFunc(roomLinger, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.rooms[r.path] == r && len(r.clients) == 0 {
+				delete(s.rooms, r.path)
+				log.Printf("Closed room %s", r.path)
+			}
+		})
+	} else {
+		r.sendPop()
+	}
+}
+
+// Pair the newcomer with whoever is waiting, sending both the name of a shared room
+func (s *Server) connectedToLobby(pill string, sock *socket) {
+	if pill == "" {
+		log.Printf("Lobby connection failed, no pill")
+		return
+	}
+
+	if s.waiting == nil {
+		s.waiting = &waiting{pill, []*socket{sock}}
+		s.notify("`" + pill + "` waiting for a match.")
+		return
+	}
+
+	other := s.waiting
+	if other.pill == pill {
+		other.sockets = append(other.sockets, sock)
+		return
+	}
+
+	a, b := pill, other.pill
+	if b < a {
+		a, b = b, a
+	}
+	join := joinMessage{a + "—" + b}
+	for _, o := range other.sockets {
+		o.send(join)
+		o.close()
+	}
+	sock.send(join)
+	sock.close()
+
+	s.waiting = nil
+	s.notify("`" + pill + "` and `" + other.pill + "` matched!")
+}
+
+func (s *Server) closeInLobby(pill string, sock *socket) {
+	if s.waiting == nil || !slices.Contains(s.waiting.sockets, sock) {
+		return
+	}
+	s.waiting.sockets = slices.DeleteFunc(s.waiting.sockets, func(o *socket) bool { return o == sock })
+	if len(s.waiting.sockets) == 0 {
+		s.notify("`" + pill + "` no longer waiting.")
+		s.waiting = nil
+	}
+}
+
+// A connection with its own writer, so that slow clients never hold the server lock
+type socket struct {
+	ws     *wsConn
+	out    chan []byte
+	mu     sync.Mutex
+	closed bool
+}
+
+func newSocket(ws *wsConn) *socket {
+	s := &socket{ws: ws, out: make(chan []byte, sendQueue)}
+	go s.write()
+	return s
+}
+
+func (s *socket) write() {
+	var err error
+	for msg := range s.out {
+		if err == nil {
+			if err = s.ws.WriteText(msg); err != nil {
+				// Unblock the reader; remaining messages are discarded
+				s.ws.conn.Close()
+			}
+		}
+	}
+	s.ws.Close()
+}
+
+// Queue a message, closing the connection if the client cannot keep up
+func (s *socket) send(v any) {
+	msg, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Encoding %T: %v", v, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.out <- msg:
+	default:
+		log.Printf("Dropping slow client")
+		s.closed = true
+		close(s.out)
+	}
+}
+
+// Close the connection once queued messages are sent
+func (s *socket) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.out)
+	}
+}
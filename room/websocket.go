@@ -0,0 +1,249 @@
+package room
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Just enough of RFC 6455 for the room protocol: text messages from browsers,
+// pings and closes. There are no extensions or subprotocols.
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+
+	// Largest message accepted from a client; boards and RTC signals are far smaller
+	maxMessage = 1 << 20
+
+	// Largest payload of control frames
+	maxControl = 125
+
+	// Close status codes
+	closeProtocolError = 1002
+	closeTooLarge      = 1009
+
+	handshakeGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+var (
+	errClosed   = errors.New("websocket closed")
+	errProtocol = errors.New("websocket protocol error")
+	errTooLarge = errors.New("websocket message too large")
+)
+
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+
+	wmu    sync.Mutex
+	closed bool // a close frame was sent
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Complete the opening handshake and take over the connection
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "expected a websocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "cannot upgrade", http.StatusInternalServerError)
+		return nil, errors.New("response does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + handshakeGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+// Read one frame, unmasking its payload
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.r, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	op = head[0] & 0x0f
+	if head[0]&0x70 != 0 {
+		return fin, op, nil, fmt.Errorf("%w: reserved bits set", errProtocol)
+	}
+	if head[1]&0x80 == 0 {
+		return fin, op, nil, fmt.Errorf("%w: unmasked client frame", errProtocol)
+	}
+
+	size := uint64(head[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+			return
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= opClose {
+		// Control frames may come between the fragments of a message, so they cannot be fragmented themselves
+		if !fin {
+			return fin, op, nil, fmt.Errorf("%w: fragmented control frame", errProtocol)
+		}
+		if size > maxControl {
+			return fin, op, nil, fmt.Errorf("%w: control frame of %d bytes", errProtocol, size)
+		}
+	}
+	if size > maxMessage {
+		return fin, op, nil, fmt.Errorf("%w: frame of %d bytes", errTooLarge, size)
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.r, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(c.r, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+// ReadMessage returns the next data message, answering pings along the way.
+// Protocol violations and oversized messages fail the connection with a close frame.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	msg, err := c.readMessage()
+	switch {
+	case errors.Is(err, errProtocol):
+		c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, closeProtocolError))
+	case errors.Is(err, errTooLarge):
+		c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, closeTooLarge))
+	}
+	return msg, err
+}
+
+func (c *wsConn) readMessage() ([]byte, error) {
+	var msg []byte
+	started := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			c.writeFrame(opClose, nil)
+			return nil, errClosed
+		case opText, opBinary:
+			if started {
+				return nil, fmt.Errorf("%w: new message inside a fragmented one", errProtocol)
+			}
+			started = true
+		case opContinuation:
+			if !started {
+				return nil, fmt.Errorf("%w: continuation without a message", errProtocol)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown opcode %d", errProtocol, op)
+		}
+		if len(msg)+len(payload) > maxMessage {
+			return nil, fmt.Errorf("%w: over %d bytes", errTooLarge, maxMessage)
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// Write a single unmasked frame, as servers do
+func (c *wsConn) writeFrame(op byte, payload []byte) error {
+	head := make([]byte, 0, 10)
+	head = append(head, 0x80|op)
+	switch n := len(payload); {
+	case n < 126:
+		head = append(head, byte(n))
+	case n <= 0xffff:
+		head = append(head, 126)
+		head = binary.BigEndian.AppendUint16(head, uint16(n))
+	default:
+		head = append(head, 127)
+		head = binary.BigEndian.AppendUint64(head, uint64(n))
+	}
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return errClosed
+	}
+	c.closed = op == opClose
+	if _, err := c.conn.Write(head); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// WriteText sends a text message
+func (c *wsConn) WriteText(msg []byte) error {
+	return c.writeFrame(opText, msg)
+}
+
+// Close sends a close frame and drops the connection
+func (c *wsConn) Close() error {
+	c.writeFrame(opClose, nil)
+	return c.conn.Close()
+}
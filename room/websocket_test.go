@@ -0,0 +1,214 @@
+package room
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Start a server echoing text messages, reporting the error that ended each connection
+func echoServer(t *testing.T) (addr string, errs <-chan error) {
+	t.Helper()
+	ch := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			msg, err := ws.ReadMessage()
+			if err != nil {
+				ch <- err
+				return
+			}
+			if err := ws.WriteText(msg); err != nil {
+				ch <- err
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://"), ch
+}
+
+type wsClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Open a connection with the handshake example of RFC 6455
+func dial(t *testing.T, addr string) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status %d, want 101", resp.StatusCode)
+	}
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Fatalf("Sec-WebSocket-Accept %q, want %q", got, want)
+	}
+	return &wsClient{t, conn, r}
+}
+
+// Send a frame masked as browsers do, with the shortest length encoding
+func (c *wsClient) send(fin bool, op byte, payload []byte) {
+	c.t.Helper()
+	head := []byte{op}
+	if fin {
+		head[0] |= 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		head = append(head, 0x80|byte(n))
+	case n <= 0xffff:
+		head = append(head, 0x80|126)
+		head = binary.BigEndian.AppendUint16(head, uint16(n))
+	default:
+		head = append(head, 0x80|127)
+		head = binary.BigEndian.AppendUint64(head, uint64(n))
+	}
+	mask := [4]byte{0x37, 0xfa, 0x21, 0x3d}
+	head = append(head, mask[:]...)
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+	if _, err := c.conn.Write(append(head, masked...)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// Read an unmasked server frame
+func (c *wsClient) receive() (op byte, payload []byte) {
+	c.t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		c.t.Fatal(err)
+	}
+	if head[0]&0x80 == 0 || head[1]&0x80 != 0 {
+		c.t.Fatalf("server frame header %x, want final and unmasked", head)
+	}
+	size := uint64(head[1])
+	switch size {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.r, ext[:])
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	payload = make([]byte, size)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		c.t.Fatal(err)
+	}
+	return head[0] & 0x0f, payload
+}
+
+func TestEchoLengths(t *testing.T) {
+	addr, _ := echoServer(t)
+	c := dial(t, addr)
+	// Around the 7-bit, 16-bit and 64-bit length encodings
+	for _, n := range []int{0, 1, 125, 126, 0xffff, 0x10000, 300000} {
+		msg := bytes.Repeat([]byte("teeko"), n/5+1)[:n]
+		c.send(true, opText, msg)
+		op, got := c.receive()
+		if op != opText || !bytes.Equal(got, msg) {
+			t.Fatalf("%d bytes: echoed opcode %d with %d bytes", n, op, len(got))
+		}
+	}
+}
+
+func TestFragmentsAndPing(t *testing.T) {
+	addr, _ := echoServer(t)
+	c := dial(t, addr)
+	c.send(false, opText, []byte("c3 "))
+	c.send(true, opPing, []byte("hi"))
+	c.send(true, opContinuation, []byte("c4"))
+	if op, payload := c.receive(); op != opPong || string(payload) != "hi" {
+		t.Fatalf("got opcode %d %q, want pong \"hi\"", op, payload)
+	}
+	if op, payload := c.receive(); op != opText || string(payload) != "c3 c4" {
+		t.Fatalf("got opcode %d %q, want text \"c3 c4\"", op, payload)
+	}
+}
+
+func TestClose(t *testing.T) {
+	addr, errs := echoServer(t)
+	c := dial(t, addr)
+	c.send(true, opClose, binary.BigEndian.AppendUint16(nil, 1000))
+	if op, _ := c.receive(); op != opClose {
+		t.Fatalf("got opcode %d, want close", op)
+	}
+	if err := <-errs; !errors.Is(err, errClosed) {
+		t.Fatalf("server read %v, want errClosed", err)
+	}
+	// The server drops the connection after its close frame
+	if _, err := c.r.ReadByte(); err != io.EOF {
+		t.Fatalf("read %v after close, want EOF", err)
+	}
+}
+
+func TestProtocolErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		send func(c *wsClient)
+		code uint16
+	}{
+		{"fragmented ping", func(c *wsClient) { c.send(false, opPing, nil) }, closeProtocolError},
+		{"fragmented close", func(c *wsClient) { c.send(false, opClose, nil) }, closeProtocolError},
+		{"long ping", func(c *wsClient) { c.send(true, opPing, make([]byte, maxControl+1)) }, closeProtocolError},
+		{"unmasked", func(c *wsClient) { c.conn.Write([]byte{0x80 | opText, 0}) }, closeProtocolError},
+		{"lone continuation", func(c *wsClient) { c.send(true, opContinuation, nil) }, closeProtocolError},
+		{"unknown opcode", func(c *wsClient) { c.send(true, 0x3, nil) }, closeProtocolError},
+		{"too large", func(c *wsClient) {
+			// The server gives up on the header, before any payload
+			head := binary.BigEndian.AppendUint64([]byte{0x80 | opText, 0x80 | 127}, maxMessage+1)
+			c.conn.Write(append(head, 0, 0, 0, 0))
+		}, closeTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, errs := echoServer(t)
+			c := dial(t, addr)
+			tc.send(c)
+			op, payload := c.receive()
+			if op != opClose || len(payload) != 2 {
+				t.Fatalf("got opcode %d %x, want close with a status", op, payload)
+			}
+			if code := binary.BigEndian.Uint16(payload); code != tc.code {
+				t.Errorf("close status %d, want %d", code, tc.code)
+			}
+			if err := <-errs; err == nil {
+				t.Error("server kept reading")
+			}
+		})
+	}
+}
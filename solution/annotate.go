@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pcarrier/teeko.cc/annotate"
+	"github.com/pcarrier/teeko.cc/record"
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Grade every move of recorded games
+func annotateCmd(args []string) {
+	fs, dbFile := newFlagSet("annotate")
+	asJSON := fs.Bool("json", false, "print annotations as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: annotate [-db file] [-json] [file] (standard input without a file)")
+		fmt.Fprintln(os.Stderr, "The file holds game records, a Board as JSON ({\"a\": …, \"m\": […]}) or a history as JSON ([12, 13, [12, 7], …]).")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	path := "-"
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	records, err := readGames(path)
+	if err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+	db := openDB(*dbFile)
+
+	type game struct {
+		Tags        map[string]string     `json:"tags"`
+		Annotations []annotate.Annotation `json:"annotations"`
+		Summary     annotate.Summary      `json:"summary"`
+	}
+	var games []game
+	for i, rec := range records {
+		annotations, err := annotate.Game(db, rec.Actions)
+		if err != nil {
+			log.Fatalf("%s: game %d: %v", path, i+1, err)
+		}
+		if !*asJSON {
+			printAnnotations(i+1, rec, annotations)
+			continue
+		}
+		tags := make(map[string]string, len(rec.Tags))
+		for _, t := range rec.Tags {
+			tags[t.Name] = t.Value
+		}
+		games = append(games, game{tags, annotations, annotate.Summarize(annotations)})
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(games); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func printAnnotations(n int, rec *record.Record, annotations []annotate.Annotation) {
+	blue, red := rec.Get(record.TagBlue), rec.Get(record.TagRed)
+	if blue == "" {
+		blue = "Blue"
+	}
+	if red == "" {
+		red = "Red"
+	}
+	fmt.Printf("Game %d: %s vs %s, %s\n", n, blue, red, rec.Get(record.TagResult))
+
+	for _, a := range annotations {
+		number := fmt.Sprintf("%d.", a.Ply/2+1)
+		if a.Ply%2 == 1 {
+			number += ".."
+		}
+		line := fmt.Sprintf("  %6s %-6v %-10v %s", number, a.Action, a.Class, describeScore(a.Score))
+		if a.Class != annotate.Best {
+			line += fmt.Sprintf(", best %v %s (swing %d)", a.Best, describeScore(a.BestScore), a.Swing)
+		}
+		fmt.Println(line)
+	}
+
+	s := annotate.Summarize(annotations)
+	for _, side := range []struct {
+		name   string
+		counts []int
+	}{{blue, s.Blue[:]}, {red, s.Red[:]}} {
+		var parts []string
+		for c, count := range side.counts {
+			if count > 0 {
+				parts = append(parts, fmt.Sprintf("%d %v", count, annotate.Class(c)))
+			}
+		}
+		fmt.Printf("  %s: %s\n", side.name, strings.Join(parts, ", "))
+	}
+	fmt.Println()
+}
+
+// Read game records, or a single game given as a Board or a history in JSON
+// as the web client sends them
+func readGames(path string) ([]*record.Record, error) {
+	in, closeInput := openInput(path)
+	defer closeInput()
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	// Records start with [ too, but are never valid JSON
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') || !json.Valid(data) {
+		return record.ReadAll(bytes.NewReader(data))
+	}
+
+	if data[0] == '{' {
+		var board teeko.Board
+		if err := json.Unmarshal(data, &board); err != nil {
+			return nil, err
+		}
+		rec, err := record.FromBoard(board)
+		if err != nil {
+			return nil, err
+		}
+		return []*record.Record{rec}, nil
+	}
+	var history []teeko.Action
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, err
+	}
+	board, err := teeko.Replay(history)
+	if err != nil {
+		return nil, err
+	}
+	rec := &record.Record{Actions: history}
+	rec.Set(record.TagResult, record.ResultOf(board))
+	return []*record.Record{rec}, nil
+}
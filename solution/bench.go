@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Compare rank queries scanning from checkpoints with the constant-time index
+func benchCmd(args []string) {
+	fs, dbFile := newFlagSet("bench")
+	count := fs.Int("n", 10000, "number of random positions to look up")
+	seed := fs.Uint64("seed", 1, "random seed")
+	fs.Parse(args)
+	db := openDB(*dbFile)
+
+	if !db.Indexed() {
+		fmt.Println("Building rank index…")
+		start := time.Now()
+		db.BuildIndex()
+		fmt.Printf("  Index built in %v\n", time.Since(start).Round(time.Millisecond))
+	}
+
+	// Random positions with 8 pieces, where checkpoint blocks are largest
+	rng := rand.New(rand.NewPCG(*seed, 0))
+	queries := make([]int, *count)
+	for i := range queries {
+		a, b := teeko.Degoedel(rng.IntN(teeko.Configs[8]), 8)
+		queries[i] = teeko.Canonical(a, b, 8)
+	}
+
+	run := func(label string, rank func(n, canonG int) int) int {
+		sum := 0
+		start := time.Now()
+		for _, g := range queries {
+			sum += rank(8, g)
+		}
+		elapsed := time.Since(start)
+		fmt.Printf("  %-10s %v per query\n", label, elapsed/time.Duration(len(queries)))
+		return sum
+	}
+	scan := run("scan", db.RankScan)
+	indexed := run("indexed", db.Rank)
+	if scan != indexed {
+		fmt.Println("  Rank mismatch between methods!")
+	}
+}
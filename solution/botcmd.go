@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+
+	"github.com/pcarrier/teeko.cc/bot"
+)
+
+// Pick a move for a position as the bot would
+func botCmd(args []string) {
+	fs, dbFile := newFlagSet("bot")
+	position := positionFlags(fs)
+	difficulty := fs.String("difficulty", string(bot.Medium), fmt.Sprintf("one of %v", bot.Difficulties))
+	seed := fs.Uint64("seed", 0, "random seed, for reproducible choices (0 picks one)")
+	fs.Parse(args)
+
+	board := position()
+	cfg, err := bot.Difficulty(*difficulty).Config()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+	db := openDB(*dbFile)
+
+	printPosition(board)
+	m, ok := bot.New(db, cfg, rand.New(rand.NewPCG(*seed, 0))).Move(board)
+	if !ok {
+		fmt.Println("  Game over")
+		return
+	}
+	fmt.Printf("  %s plays %v: %s (%d), seed %d\n", turnName(board), m.Action, describeScore(m.Score), m.Score, *seed)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Convert a database between the v1 (full) and v2/v3 (canonical) formats,
+// checking that every position's score survives compression
+func convertCmd(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	in := fs.String("in", "teeko.db", "input database (v1, v2 or v3)")
+	out := fs.String("out", "", "output database")
+	version := fs.Int("version", 0, "output version (default: 2 for v1 input, 1 otherwise)")
+	fs.Parse(args)
+	if *out == "" || *version < 0 || *version > 3 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	t, err := teeko.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var db *teeko.DB
+	switch t := t.(type) {
+	case *teeko.FullDB:
+		if *version == 0 {
+			*version = 2
+		}
+		if *version != 1 {
+			fmt.Printf("Compressing %s…\n", *in)
+			db = t.Compress()
+			if checkRoundTrip(t, db.Expand()) > 0 {
+				os.Exit(1)
+			}
+		}
+	case *teeko.DB:
+		if *version == 0 {
+			*version = 1
+		}
+		db = t
+	}
+
+	fmt.Printf("Saving %s as v%d…\n", *out, *version)
+	switch *version {
+	case 1:
+		full, ok := t.(*teeko.FullDB)
+		if !ok {
+			full = expandChecked(db)
+		}
+		err = full.Save(*out)
+	case 2:
+		err = db.Save(*out)
+	case 3:
+		err = db.SaveV3(*out)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Expand canonical scores, checking that compressing them again gives the same database
+func expandChecked(db *teeko.DB) *teeko.FullDB {
+	fmt.Println("Expanding to full tables…")
+	full := db.Expand()
+	back := full.Compress()
+	for n := range 9 {
+		if !slices.Equal(back.Scores[n], db.Scores[n]) || !slices.Equal(back.Checkpoints[n], db.Checkpoints[n]) {
+			log.Fatalf("%d-piece table does not survive compression", n)
+		}
+	}
+	return full
+}
+
+// Compare full tables before and after compression, reporting the first mismatches
+func checkRoundTrip(want, got *teeko.FullDB) int {
+	mismatches := 0
+	for n := range 9 {
+		for g, s := range want.Scores[n] {
+			if got.Scores[n][g] == s {
+				continue
+			}
+			mismatches++
+			if mismatches <= 10 {
+				a, b := teeko.Degoedel(g, n)
+				fmt.Printf("  %d pieces, index %d: %d became %d\n", n, g, s, got.Scores[n][g])
+				printBoard(a, b)
+			}
+		}
+	}
+	if mismatches > 0 {
+		fmt.Printf("%d positions do not survive compression\n", mismatches)
+	}
+	return mismatches
+}
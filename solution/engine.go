@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+
+	"github.com/pcarrier/teeko.cc/engine"
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Speak the Teeko Engine Protocol on standard input and output
+func engineCmd(args []string) {
+	fs, dbFile := newFlagSet("engine")
+	seed := fs.Uint64("seed", 0, "random seed for difficulties below perfect (0 picks one)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: engine [flags], then commands on standard input (see package engine)")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+	// Standard output belongs to the protocol
+	var t teeko.ScoreTable
+	if db, err := teeko.Open(*dbFile); err != nil {
+		log.Printf("%v; searching without a database", err)
+	} else {
+		t = db
+	}
+	e := engine.New(t, rand.New(rand.NewPCG(*seed, 0)))
+	if err := e.Run(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
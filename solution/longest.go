@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Show the longest forced win for each side
+func longestCmd(args []string) {
+	fs, dbFile := newFlagSet("longest")
+	line := fs.Bool("line", false, "also show the forced lines")
+	fs.Parse(args)
+	db := openDB(*dbFile)
+
+	fmt.Println("Finding longest forced wins…")
+	aWin, bWin := db.LongestWins()
+	for _, w := range []*teeko.Win{aWin, bWin} {
+		if w == nil {
+			continue
+		}
+		printWin(winLabel(w), w)
+		if *line {
+			fmt.Println()
+			printLine(db, teeko.TableBoard(w.A, w.B, w.Pieces), 0)
+		}
+	}
+}
+
+// Name the winner of a table entry: tables hold positions from the mover's
+// perspective, and the piece count tells whether the mover is Blue or Red
+func winLabel(w *teeko.Win) string {
+	mover := turnName(teeko.TableBoard(w.A, w.B, w.Pieces))
+	if w.Score > 0 {
+		return sideName(mover) + ", to move"
+	}
+	if mover == "a" {
+		return sideName("b") + ", not to move"
+	}
+	return sideName("a") + ", not to move"
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pcarrier/teeko.cc/bot"
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+const playHelp = `Commands:
+  c3, c3-d4   drop a piece, or move one to a neighbouring square
+  undo        take back your last action and the bot's reply
+  eval        toggle the evaluation of every candidate action
+  hint        show the best action
+  new         start a new game
+  quit        leave
+`
+
+// Play against the bot in the terminal
+func playCmd(args []string) {
+	fs, dbFile := newFlagSet("play")
+	side := fs.String("side", "a", "your side: a (Blue, moves first) or b (Red)")
+	difficulty := fs.String("difficulty", string(bot.Medium), fmt.Sprintf("one of %v", bot.Difficulties))
+	eval := fs.Bool("eval", false, "show the evaluation of every candidate action")
+	color := fs.Bool("color", isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "", "use ANSI colours")
+	seed := fs.Uint64("seed", 0, "random seed, for reproducible games (0 picks one)")
+	fs.Parse(args)
+
+	if *side != "a" && *side != "b" {
+		log.Fatalf("side must be a or b, not %q", *side)
+	}
+	cfg, err := bot.Difficulty(*difficulty).Config()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *seed == 0 {
+		*seed = rand.Uint64()
+	}
+	t, err := teeko.Open(*dbFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := &game{
+		table: t,
+		bot:   bot.New(t, cfg, rand.New(rand.NewPCG(*seed, 0))),
+		human: *side,
+		eval:  *eval,
+		style: boardStyle{color: *color},
+		board: teeko.Board{P: true},
+		in:    bufio.NewScanner(os.Stdin),
+	}
+	fmt.Printf("You play %s against the %s bot (seed %d). Type help for commands.\n", sideName(*side), cfg.Name, *seed)
+	g.run()
+}
+
+// Whether f is an interactive terminal rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func sideName(turn string) string {
+	if turn == "a" {
+		return "A (Blue)"
+	}
+	return "B (Red)"
+}
+
+type game struct {
+	table teeko.ScoreTable
+	bot   *bot.Bot
+	human string // "a" or "b"
+	eval  bool
+	style boardStyle
+	board teeko.Board
+	in    *bufio.Scanner
+}
+
+func (g *game) run() {
+	for {
+		g.show()
+		over := teeko.IsWin(g.board.A) || teeko.IsWin(g.board.B)
+		botTurn := !over && turnName(g.board) != g.human
+		if botTurn {
+			if m, ok := g.bot.Move(g.board); ok {
+				if err := g.apply(m.Action); err != nil {
+					panic(err)
+				}
+				fmt.Printf("Bot plays %v\n", m.Action)
+				continue
+			}
+		}
+		switch {
+		case over:
+			g.announce()
+		case botTurn:
+			fmt.Println("The bot has no legal action. Type new, undo or quit.")
+		case g.eval:
+			g.showMoves()
+		}
+		if !g.prompt() {
+			return
+		}
+	}
+}
+
+func (g *game) show() {
+	fmt.Println()
+	var mark uint32
+	if n := len(g.board.M); n > 0 {
+		last := g.board.M[n-1]
+		mark = 1 << last.To
+		if !last.IsDrop() {
+			mark |= 1 << last.From
+		}
+	}
+	g.style.mark = mark
+	writeBoard(os.Stdout, g.board.A, g.board.B, g.style)
+}
+
+func (g *game) announce() {
+	winner := "a"
+	if teeko.IsWin(g.board.B) {
+		winner = "b"
+	}
+	if winner == g.human {
+		fmt.Printf("You win in %d plies! Type new, undo or quit.\n", len(g.board.M))
+	} else {
+		fmt.Printf("The bot wins in %d plies. Type new, undo or quit.\n", len(g.board.M))
+	}
+}
+
+// Candidate actions of the player to move, best first
+func (g *game) moves() []teeko.Move {
+	moves := teeko.GenerateMoves(g.table, g.board)
+	slices.SortStableFunc(moves, func(x, y teeko.Move) int { return int(y.Score) - int(x.Score) })
+	return moves
+}
+
+func (g *game) showMoves() {
+	for _, m := range g.moves() {
+		fmt.Printf("  %-6v %s\n", m.Action, describeScore(m.Score))
+	}
+}
+
+// Read commands until one changes the game; false to quit
+func (g *game) prompt() bool {
+	for {
+		fmt.Printf("%s> ", turnName(g.board))
+		if !g.in.Scan() {
+			fmt.Println()
+			return false
+		}
+		switch cmd := strings.ToLower(strings.TrimSpace(g.in.Text())); cmd {
+		case "":
+		case "help", "?":
+			fmt.Print(playHelp)
+		case "quit", "exit":
+			return false
+		case "new":
+			g.board = teeko.Board{P: true}
+			return true
+		case "undo":
+			if g.undo() {
+				return true
+			}
+			fmt.Println("Nothing to undo")
+		case "eval":
+			g.eval = !g.eval
+			if g.eval {
+				g.showMoves()
+			}
+		case "hint":
+			if moves := g.moves(); len(moves) > 0 {
+				fmt.Printf("  %v: %s\n", moves[0].Action, describeScore(moves[0].Score))
+			}
+		default:
+			action, err := teeko.ParseAction(cmd)
+			if err == nil {
+				err = g.apply(action)
+			}
+			if err == nil {
+				return true
+			}
+			fmt.Println(err)
+		}
+	}
+}
+
+func (g *game) apply(action teeko.Action) error {
+	next, err := g.board.Apply(action)
+	if err != nil {
+		return err
+	}
+	g.board = next
+	return nil
+}
+
+// Take back the bot's reply and the human's last action, like the web UI
+// against a bot; false when the human has not played yet
+func (g *game) undo() bool {
+	first := 0 // ply of the human's first action
+	if g.human == "b" {
+		first = 1
+	}
+	if len(g.board.M) <= first {
+		return false
+	}
+	for {
+		prev, err := g.board.Undo()
+		if err != nil {
+			// There is history, as checked above
+			panic(err)
+		}
+		g.board = prev
+		if turnName(g.board) == g.human {
+			return true
+		}
+	}
+}
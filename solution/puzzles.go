@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pcarrier/teeko.cc/puzzle"
+)
+
+// Export positions with a single winning move as puzzles
+func puzzlesCmd(args []string) {
+	fs, dbFile := newFlagSet("puzzles")
+	winIn := fs.Int("win-in", 0, "only wins of this length, as query reports them (0 for any)")
+	pieces := fs.String("pieces", "", "comma-separated piece counts to scan, 8 for the move phase (default all)")
+	limit := fs.Int("limit", 0, "keep at most this many puzzles (0 for all)")
+	trials := fs.Int("trials", puzzle.DefaultTrials, "attempts per bot when estimating difficulty")
+	seed := fs.Uint64("seed", 1, "random seed for the difficulty estimate")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of worker goroutines")
+	out := fs.String("out", "", "write puzzles as JSON to this file instead of standard output")
+	fs.Parse(args)
+
+	opts := puzzle.Options{WinIn: *winIn, Trials: *trials, Seed: *seed, Workers: *workers}
+	if *pieces != "" {
+		for _, f := range strings.Split(*pieces, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil || n < 0 || n > 8 {
+				log.Fatalf("bad piece count %q", f)
+			}
+			opts.Pieces = append(opts.Pieces, n)
+		}
+	}
+
+	db := openDB(*dbFile)
+	if !db.Indexed() {
+		fmt.Fprintln(os.Stderr, "Indexing…")
+		db.BuildIndex()
+	}
+	puzzles := puzzle.Find(db, opts)
+	fmt.Fprintf(os.Stderr, "%d puzzles\n", len(puzzles))
+	if *limit > 0 && len(puzzles) > *limit {
+		puzzles = puzzles[:*limit]
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(puzzles); err != nil {
+		log.Fatal(err)
+	}
+}
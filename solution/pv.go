@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Show the principal variation from a position
+func pvCmd(args []string) {
+	fs, dbFile := newFlagSet("pv")
+	position := positionFlags(fs)
+	plies := fs.Int("plies", 20, "length of the line for drawn positions")
+	fs.Parse(args)
+
+	board := position()
+	db := openDB(*dbFile)
+	printLine(db, board, *plies)
+}
+
+// Print a position, then each move of best play with the board it leads to
+func printLine(t teeko.ScoreTable, board teeko.Board, plies int) {
+	mover, other := board.Mover()
+	score := t.Lookup(mover, other, board.Pieces())
+	printPosition(board)
+	fmt.Printf("  %s to move: %s (%d)\n", turnName(board), describePosition(score), score)
+
+	for i, p := range teeko.PrincipalVariation(t, board, plies) {
+		fmt.Printf("\n  %d. %s %v  %s (%d)\n", i+1, turnName(board), p.Action, describeScore(p.Score), p.Score)
+		printPosition(p.Board)
+		board = p.Board
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Score a position and each of its moves
+func queryCmd(args []string) {
+	fs, dbFile := newFlagSet("query")
+	position := positionFlags(fs)
+	fs.Parse(args)
+
+	board := position()
+	db := openDB(*dbFile)
+
+	mover, other := board.Mover()
+	printPosition(board)
+	score := db.Score(mover, other)
+	fmt.Printf("  %s to move: %s (%d)\n", turnName(board), describePosition(score), score)
+	if teeko.IsWin(board.A) || teeko.IsWin(board.B) {
+		fmt.Println("  Game over")
+		return
+	}
+
+	moves := db.Moves(board)
+	slices.SortStableFunc(moves, func(x, y teeko.Move) int { return int(y.Score) - int(x.Score) })
+	for _, m := range moves {
+		fmt.Printf("  %-6v %s (%d)\n", m.Action, describeScore(m.Score), m.Score)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pcarrier/teeko.cc/bot"
+	"github.com/pcarrier/teeko.cc/rating"
+	"github.com/pcarrier/teeko.cc/record"
+)
+
+// Update Glicko-2 ratings from game records and self-play results
+func rateCmd(args []string) {
+	fs := flag.NewFlagSet("rate", flag.ExitOnError)
+	tableFile := fs.String("table", "ratings.json", "ratings table, created if missing and updated in place")
+	byDate := fs.Bool("by-date", false, "make a rating period of each Date header instead of each file")
+	tau := fs.Float64("tau", 0, "system constant constraining volatility changes (default: keep the table's)")
+	dryRun := fs.Bool("n", false, "print the updated table without saving it")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: rate [flags] [record or selfplay JSON file…]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	table, err := rating.Load(*tableFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *tau > 0 {
+		table.Tau = *tau
+	}
+
+	for _, path := range fs.Args() {
+		var periods [][]rating.Result
+		if strings.HasSuffix(path, ".json") {
+			periods = selfplayPeriods(path)
+		} else {
+			periods = recordPeriods(path, *byDate)
+		}
+		for _, results := range periods {
+			table.RatePeriod(results)
+		}
+	}
+
+	printRatings(table)
+	if !*dryRun {
+		if err := table.Save(*tableFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// Results of the finished games of a record file, in one period or one per date
+func recordPeriods(path string, byDate bool) [][]rating.Result {
+	in, closeInput := openInput(path)
+	defer closeInput()
+
+	byPeriod := make(map[string][]rating.Result)
+	rd := record.NewReader(in)
+	for i := 1; ; i++ {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("%s: game %d: %v", path, i, err)
+		}
+		if err := rec.Validate(); err != nil {
+			log.Printf("%s: game %d: skipped: %v", path, i, err)
+			continue
+		}
+		blue, red := rec.Get(record.TagBlue), rec.Get(record.TagRed)
+		if blue == "" || red == "" {
+			log.Printf("%s: game %d: skipped: missing player names", path, i)
+			continue
+		}
+		var score float64
+		switch rec.Get(record.TagResult) {
+		case record.BlueWins:
+			score = 1
+		case record.RedWins:
+			score = 0
+		case record.Draw:
+			score = 0.5
+		default:
+			continue
+		}
+		period := ""
+		if byDate {
+			period = rec.Get(record.TagDate)
+		}
+		byPeriod[period] = append(byPeriod[period], rating.Result{A: blue, B: red, Score: score})
+	}
+
+	// Dates as YYYY.MM.DD sort chronologically
+	dates := make([]string, 0, len(byPeriod))
+	for date := range byPeriod {
+		dates = append(dates, date)
+	}
+	slices.Sort(dates)
+	periods := make([][]rating.Result, len(dates))
+	for i, date := range dates {
+		periods[i] = byPeriod[date]
+	}
+	return periods
+}
+
+// Results of a selfplay -out file, all in one period
+func selfplayPeriods(path string) [][]rating.Result {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var out struct {
+		Matches []bot.MatchResult `json:"matches"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+	var results []rating.Result
+	for _, m := range out.Matches {
+		for _, g := range []struct {
+			n     int
+			score float64
+		}{{m.Wins, 1}, {m.Draws, 0.5}, {m.Losses, 0}} {
+			for range g.n {
+				results = append(results, rating.Result{A: m.A, B: m.B, Score: g.score})
+			}
+		}
+	}
+	return [][]rating.Result{results}
+}
+
+func printRatings(t *rating.Table) {
+	fmt.Printf("%d rating periods\n", t.Periods)
+	fmt.Printf("%-4s %-20s %7s %5s %6s %6s %6s %6s\n", "#", "player", "rating", "rd", "games", "wins", "draws", "losses")
+	for i, p := range t.Players {
+		mark := ""
+		if p.Provisional() {
+			mark = "?"
+		}
+		fmt.Printf("%-4d %-20s %6.0f%-1s %5.0f %6d %6d %6d %6d\n",
+			i+1, p.Name, p.Rating, mark, p.RD, p.Games, p.Wins, p.Draws, p.Losses)
+	}
+}
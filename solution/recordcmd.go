@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pcarrier/teeko.cc/record"
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Write Board JSON from the web client as game records, the reverse of validate -json
+func recordCmd(args []string) {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	blue := fs.String("blue", "", "name of the Blue player")
+	red := fs.String("red", "", "name of the Red player")
+	date := fs.String("date", "", "date of the games, as YYYY.MM.DD")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: record [-blue name] [-red name] [-date YYYY.MM.DD] [Board JSON file] (standard input without a file)")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	path := "-"
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	in, closeInput := openInput(path)
+	defer closeInput()
+
+	// Boards follow each other, one per line as validate -json prints them
+	dec := json.NewDecoder(in)
+	for i := 1; ; i++ {
+		var board teeko.Board
+		if err := dec.Decode(&board); err == io.EOF {
+			return
+		} else if err != nil {
+			log.Fatalf("%s: board %d: %v", path, i, err)
+		}
+		rec, err := record.FromBoard(board)
+		if err != nil {
+			log.Fatalf("%s: board %d: %v", path, i, err)
+		}
+		for name, value := range map[string]string{record.TagBlue: *blue, record.TagRed: *red, record.TagDate: *date} {
+			if value != "" {
+				rec.Set(name, value)
+			}
+		}
+		if i > 1 {
+			fmt.Println()
+		}
+		if err := record.Write(os.Stdout, rec); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/pcarrier/teeko.cc/room"
+)
+
+// Serve multiplayer rooms and the lobby, replacing ws/index.ts
+func roomsCmd(args []string) {
+	fs := flag.NewFlagSet("rooms", flag.ExitOnError)
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8081"
+	}
+	addr := fs.String("addr", ":"+port, "address to listen on (defaults to $PORT)")
+	webhook := fs.String("discord", os.Getenv("DISCORD_WEBHOOK"), "Discord webhook for lobby notifications (defaults to $DISCORD_WEBHOOK)")
+	fs.Parse(args)
+
+	fmt.Printf("WebSocket server running on %s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, room.NewServer(room.Discord(*webhook))))
+}
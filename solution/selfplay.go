@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/pcarrier/teeko.cc/bot"
+	"github.com/pcarrier/teeko.cc/record"
+)
+
+// Play seeded games between bot configurations and report their strength
+func selfplayCmd(args []string) {
+	fs, dbFile := newFlagSet("selfplay")
+	a := fs.String("a", string(bot.Medium), "first bot: a difficulty or a JSON config")
+	b := fs.String("b", string(bot.Hard), "second bot: a difficulty or a JSON config")
+	all := fs.Bool("all", false, "play every pair of difficulties instead of -a against -b")
+	games := fs.Int("games", 1000, "games per pair, each bot playing Blue in half of them")
+	seed := fs.Uint64("seed", 1, "random seed")
+	maxPlies := fs.Int("max-plies", 200, "actions after which a game is a draw")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of worker goroutines")
+	out := fs.String("out", "", "write results as JSON to this file instead of standard output")
+	records := fs.String("records", "", "also write every game to this record file")
+	fs.Parse(args)
+	if *games < 0 {
+		log.Fatalf("-games must be at least 0, not %d", *games)
+	}
+	if *maxPlies <= 0 {
+		log.Fatalf("-max-plies must be at least 1, not %d", *maxPlies)
+	}
+
+	var pairs [][2]bot.Config
+	if *all {
+		for i, x := range bot.Difficulties {
+			for _, y := range bot.Difficulties[i+1:] {
+				cx, _ := x.Config()
+				cy, _ := y.Config()
+				pairs = append(pairs, [2]bot.Config{cx, cy})
+			}
+		}
+	} else {
+		ca, err := bot.ParseConfig(*a)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cb, err := bot.ParseConfig(*b)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pairs = append(pairs, [2]bot.Config{ca, cb})
+	}
+	db := openDB(*dbFile)
+
+	var recordFile *os.File
+	if *records != "" {
+		f, err := os.Create(*records)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		recordFile = f
+	}
+
+	var results []bot.MatchResult
+	for _, pair := range pairs {
+		m := bot.Match{A: pair[0], B: pair[1], Games: *games, Seed: *seed, MaxPlies: *maxPlies, Workers: *workers}
+		var each func(int, bool, bot.Game)
+		if recordFile != nil {
+			each = func(i int, aIsBlue bool, g bot.Game) {
+				writeSelfplayRecord(recordFile, m, i, aIsBlue, g)
+			}
+		}
+		r := m.Play(db, each)
+		fmt.Fprintf(os.Stderr, "%s vs %s: +%d =%d -%d, %.1f plies, Elo %+.0f ± %.0f\n",
+			r.A, r.B, r.Wins, r.Draws, r.Losses, r.AvgPlies, r.Elo, r.EloMargin)
+		results = append(results, r)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(struct {
+		Games    int               `json:"gamesPerPair"`
+		Seed     uint64            `json:"seed"`
+		MaxPlies int               `json:"maxPlies"`
+		Matches  []bot.MatchResult `json:"matches"`
+	}{*games, *seed, *maxPlies, results}); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func writeSelfplayRecord(f *os.File, m bot.Match, i int, aIsBlue bool, g bot.Game) {
+	blue, red := m.A.Name, m.B.Name
+	if !aIsBlue {
+		blue, red = red, blue
+	}
+	rec := &record.Record{Actions: g.Actions}
+	rec.Set(record.TagBlue, blue)
+	rec.Set(record.TagRed, red)
+	rec.Set(record.TagVariant, record.Variant)
+	switch g.Winner {
+	case 0:
+		rec.Set(record.TagResult, record.BlueWins)
+	case 1:
+		rec.Set(record.TagResult, record.RedWins)
+	default:
+		rec.Set(record.TagResult, record.Draw)
+	}
+	rec.Set("Event", "selfplay")
+	rec.Set("Round", fmt.Sprintf("%d.%d", m.Seed, i))
+	if err := record.Write(f, rec); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintln(f)
+}
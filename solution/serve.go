@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/pcarrier/teeko.cc/analysis"
+)
+
+// Serve analysis over HTTP
+func serveCmd(args []string) {
+	fs, dbFile := newFlagSet("serve")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	cacheSize := fs.Int("cache", 1<<16, "number of analyzed positions to keep in memory")
+	fs.Parse(args)
+
+	db := openDB(*dbFile)
+	fmt.Println("Indexing…")
+	db.BuildIndex()
+
+	fmt.Printf("Listening on %s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, analysis.New(db, *cacheSize).Handler()))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Compute the complete solution from scratch and save it
+func solveCmd(args []string) {
+	fs, dbFile := newFlagSet("solve")
+	v1File := fs.String("v1", "", "also save the full tables in v1 format to this file")
+	checkpoint := fs.String("checkpoint", "", "periodically save play phase progress to this file")
+	every := fs.Duration("checkpoint-every", 10*time.Minute, "minimum time between checkpoints")
+	resume := fs.Bool("resume", false, "continue from the -checkpoint file")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of worker goroutines")
+	full := fs.Bool("full", false, "solve full tables then compress them, instead of solving canonical positions directly")
+	lowMemory := fs.Bool("low-memory", false, "with -full, decode positions on the fly instead of caching them (about 300 MB instead of 1 GB)")
+	compareWorkers := fs.Int("compare-workers", 0, "solve again with this many workers and fail unless both databases are identical")
+	fs.Parse(args)
+	if *lowMemory && !*full {
+		log.Fatal("-low-memory requires -full")
+	}
+
+	s := newSolver(*full)
+	s.Log = os.Stdout
+	s.Workers = *workers
+	s.LowMemory = *lowMemory
+	s.CheckpointFile = *checkpoint
+	s.CheckpointEvery = *every
+	if *resume {
+		if *checkpoint == "" {
+			log.Fatal("-resume requires -checkpoint")
+		}
+		if err := s.Resume(); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := s.ComputePlay(); err != nil {
+		log.Fatal(err)
+	}
+	s.ComputeDrop()
+	aWin, bWin := s.LongestWins()
+	if aWin != nil {
+		printWin(winLabel(aWin), aWin)
+	}
+	if bWin != nil {
+		printWin(winLabel(bWin), bWin)
+	}
+	if *v1File != "" {
+		fmt.Printf("Saving %s…\n", *v1File)
+		if err := s.Tables().Save(*v1File); err != nil {
+			log.Fatal(err)
+		}
+	}
+	db := s.Compress()
+	fmt.Printf("Saving %s…\n", *dbFile)
+	if err := db.Save(*dbFile); err != nil {
+		log.Fatal(err)
+	}
+
+	if *compareWorkers > 0 {
+		compareSolve(db, *compareWorkers, *full, *lowMemory)
+	}
+}
+
+// Solve again with a different worker count and check the result is byte-identical
+func compareSolve(db *teeko.DB, workers int, full, lowMemory bool) {
+	fmt.Printf("Solving again with %d workers…\n", workers)
+	s := newSolver(full)
+	s.Log = os.Stdout
+	s.Workers = workers
+	s.LowMemory = lowMemory
+	if err := s.ComputePlay(); err != nil {
+		log.Fatal(err)
+	}
+	s.ComputeDrop()
+	other := s.Compress()
+
+	var want, got bytes.Buffer
+	if err := db.Write(&want); err != nil {
+		log.Fatal(err)
+	}
+	if err := other.Write(&got); err != nil {
+		log.Fatal(err)
+	}
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		log.Fatal("Solves with different worker counts differ")
+	}
+	fmt.Println("  Databases are identical")
+}
+
+func newSolver(full bool) *teeko.Solver {
+	if full {
+		return teeko.NewSolver()
+	}
+	fmt.Println("Indexing canonical positions…")
+	return teeko.NewCanonicalSolver()
+}
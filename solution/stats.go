@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Summarize outcomes for each piece count
+func statsCmd(args []string) {
+	fs, dbFile := newFlagSet("stats")
+	fs.Parse(args)
+	db := openDB(*dbFile)
+
+	total := 0
+	for n := range 9 {
+		ties, aWins, bWins, aAdvantage, bAdvantage, illegal := 0, 0, 0, 0, 0, 0
+		for _, s := range db.Scores[n] {
+			switch {
+			case s < teeko.ScoreBWin:
+				illegal++
+			case s == teeko.ScoreTie:
+				ties++
+			case s > teeko.ScoreHeuristicMax:
+				aWins++
+			case s < -teeko.ScoreHeuristicMax:
+				bWins++
+			case s > 0:
+				aAdvantage++
+			case s < 0:
+				bAdvantage++
+			}
+		}
+		total += db.Counts[n]
+		fmt.Printf("  %d pieces: %d canonical of %d, %d draws (%d A+, %d even, %d B+), %d A wins, %d B wins, %d illegal\n",
+			n, db.Counts[n], teeko.Configs[n], aAdvantage+ties+bAdvantage, aAdvantage, ties, bAdvantage, aWins, bWins, illegal)
+	}
+	fmt.Printf("  Total: %d canonical positions\n", total)
+	fmt.Printf("  Initial position: %s (%d)\n", describePosition(db.Scores[0][0]), db.Scores[0][0])
+}
@@ -5,916 +5,215 @@
 package main
 
 import (
-	"encoding/binary"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math/bits"
 	"os"
-	"runtime"
-	"sync"
-	"sync/atomic"
-	"time"
-)
+	"sort"
+	"strings"
 
-// Board constants
-const (
-	Edge = 5
-	Size = Edge * Edge
+	"github.com/pcarrier/teeko.cc/teeko"
 )
 
-// Score values
-const (
-	ScoreTie     int8 = 0
-	ScoreBWin    int8 = -126
-	ScoreAWin    int8 = 126
-	ScoreNone    int8 = -127
-	ScoreIllegal int8 = -128
-	// Heuristic scores for drawn positions (range -80 to +80)
-	// ±81 to ±126 are reserved for forced wins (up to 45 moves)
-	ScoreHeuristicMax int8 = 80
-)
-
-
-// Precomputed values
-var (
-	choose    [32][32]int
-	patterns  = [9]int{1, 1, 2, 3, 6, 10, 20, 35, 70}
-	positions = [9]int{1, 25, 300, 2300, 12650, 53130, 177100, 480700, 1081575}
-	configs   [9]int
-)
-
-// Precomputed neighbor masks for each position (same as NEIGHS_BY_POSITION in model.ts)
-var neighs = [Size]uint32{
-	98, 229, 458, 916, 776, 3139, 7335, 14670, 29340, 24856, 100448, 234720,
-	469440, 938880, 795392, 3214336, 7511040, 15022080, 30044160, 25452544,
-	2195456, 5472256, 10944512, 21889024, 9175040,
-}
-
-// Winning positions (same as WINNING_POSITIONS in model.ts)
-var wins = map[uint32]bool{
-	99: true, 198: true, 396: true, 792: true, 3168: true, 6336: true, 12672: true, 25344: true,
-	101376: true, 202752: true, 405504: true, 811008: true, 3244032: true, 6488064: true,
-	12976128: true, 25952256: true, 15: true, 30: true, 480: true, 960: true, 15360: true,
-	30720: true, 491520: true, 983040: true, 15728640: true, 31457280: true, 33825: true,
-	67650: true, 135300: true, 270600: true, 541200: true, 1082400: true, 2164800: true,
-	4329600: true, 8659200: true, 17318400: true, 266305: true, 532610: true, 8521760: true,
-	17043520: true, 34952: true, 69904: true, 1118464: true, 2236928: true,
-}
-
-// Winning patterns as slice for iteration
-var winPatterns = []uint32{
-	99, 198, 396, 792, 3168, 6336, 12672, 25344,
-	101376, 202752, 405504, 811008, 3244032, 6488064,
-	12976128, 25952256, 15, 30, 480, 960, 15360,
-	30720, 491520, 983040, 15728640, 31457280, 33825,
-	67650, 135300, 270600, 541200, 1082400, 2164800,
-	4329600, 8659200, 17318400, 266305, 532610, 8521760,
-	17043520, 34952, 69904, 1118464, 2236928,
-}
-
-// Square priority for move ordering (center = best)
-// Stored as bitmasks for each priority level (4=best, 0=worst)
-var priorityMasks = [5]uint32{
-	0b1000100000000000000010001, // priority 0: corners
-	0b0111010001100011000101110, // priority 1: edges
-	0b0000001010000000101000000, // priority 2: inner ring corners
-	0b0000000100010100010000000, // priority 3: inner ring edges
-	0b0000000000001000000000000, // priority 4: center
-}
-
-// D4 symmetry transformation tables (8 symmetries of the square)
-// symTables[sym][pos] = new position after applying symmetry
-var symTables = [8][25]int{
-	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24},          // identity
-	{20, 15, 10, 5, 0, 21, 16, 11, 6, 1, 22, 17, 12, 7, 2, 23, 18, 13, 8, 3, 24, 19, 14, 9, 4},          // rot90 CCW
-	{24, 23, 22, 21, 20, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0},          // rot180
-	{4, 9, 14, 19, 24, 3, 8, 13, 18, 23, 2, 7, 12, 17, 22, 1, 6, 11, 16, 21, 0, 5, 10, 15, 20},          // rot270 CCW
-	{4, 3, 2, 1, 0, 9, 8, 7, 6, 5, 14, 13, 12, 11, 10, 19, 18, 17, 16, 15, 24, 23, 22, 21, 20},          // flip H
-	{20, 21, 22, 23, 24, 15, 16, 17, 18, 19, 10, 11, 12, 13, 14, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4},          // flip V
-	{0, 5, 10, 15, 20, 1, 6, 11, 16, 21, 2, 7, 12, 17, 22, 3, 8, 13, 18, 23, 4, 9, 14, 19, 24},          // reflect main diagonal
-	{24, 19, 14, 9, 4, 23, 18, 13, 8, 3, 22, 17, 12, 7, 2, 21, 16, 11, 6, 1, 20, 15, 10, 5, 0},          // reflect anti-diagonal
-}
-
-// Transform a bitmask using symmetry table
-func transformMask(mask uint32, sym int) uint32 {
-	var result uint32
-	for p := mask; p != 0; {
-		bit := p & -p
-		p ^= bit
-		result |= 1 << symTables[sym][bits.TrailingZeros32(bit)]
-	}
-	return result
-}
-
-// Find canonical form (minimum Goedel index among all 8 variants)
-func canonical(a, b uint32, n int) int {
-	minG := goedel(a, b, n)
-	for s := 1; s < 8; s++ {
-		if g := goedel(transformMask(a, s), transformMask(b, s), n); g < minG {
-			minG = g
-		}
-	}
-	return minG
-}
-
-// Check if a Goedel index is canonical
-func isCanonical(g, n int) bool {
-	a, b := degoedel(g, n)
-	return canonical(a, b, n) == g
-}
-
-// Rank query: count canonical positions with Goedel index < canonG
-func rankQuery(n, canonG int) int {
-	blockIdx := canonG / BLOCK_SIZE
-	rank := checkpoints[n][blockIdx]
-
-	// Scan from block start to canonG, counting canonicals
-	for g := blockIdx * BLOCK_SIZE; g < canonG; g++ {
-		if isCanonical(g, n) {
-			rank++
-		}
-	}
-	return rank
-}
-
-// Lookup score for a position using canonical form
-func lookupScore(a, b uint32, n int) int8 {
-	canonG := canonical(a, b, n)
-	return canonicalScores[n][rankQuery(n, canonG)]
-}
-
-// Store score for a position using canonical form
-func storeScore(a, b uint32, n int, score int8) {
-	canonG := canonical(a, b, n)
-	canonicalScores[n][rankQuery(n, canonG)] = score
-}
-
-// Build checkpoints for piece count n
-func buildCheckpoints(n int) {
-	numBlocks := (configs[n] + BLOCK_SIZE - 1) / BLOCK_SIZE
-	checkpoints[n] = make([]int, numBlocks+1)
-
-	rank := 0
-	for g := 0; g < configs[n]; g++ {
-		if g%BLOCK_SIZE == 0 {
-			checkpoints[n][g/BLOCK_SIZE] = rank
-		}
-		if isCanonical(g, n) {
-			rank++
-		}
-	}
-	checkpoints[n][numBlocks] = rank
-	canonicalCounts[n] = rank
-	canonicalScores[n] = make([]int8, rank)
-}
-
-// Compress full score table into canonical format
-// Called after computation, before saving
-func compressScores() {
-	fmt.Println("Compressing to canonical format…")
-	start := time.Now()
-
-	// Need position cache for degoedel
-	initPosCache()
-
-	var totalFull, totalCanonical int
-	for n := range 9 {
-		totalFull += configs[n]
-		buildCheckpoints(n)
-		totalCanonical += canonicalCounts[n]
-
-		// Copy scores from full table to canonical table
-		rank := 0
-		for g := 0; g < configs[n]; g++ {
-			if isCanonical(g, n) {
-				canonicalScores[n][rank] = scores[n][g]
-				rank++
-			}
-		}
-	}
-
-	// Clear the full score tables to free memory
-	for n := range 9 {
-		scores[n] = nil
+type command struct {
+	run   func(args []string)
+	usage string
+}
+
+var commands = map[string]command{
+	"solve":    {solveCmd, "compute the complete solution and save it"},
+	"annotate": {annotateCmd, "grade every move of recorded games"},
+	"query":    {queryCmd, "score a position and its moves"},
+	"rate":     {rateCmd, "update Glicko-2 ratings from games and self-play"},
+	"stats":    {statsCmd, "summarize outcomes per piece count"},
+	"longest":  {longestCmd, "show the longest forced win for each side"},
+	"play":     {playCmd, "play against the bot in the terminal"},
+	"puzzles":  {puzzlesCmd, "export positions with a single winning move"},
+	"pv":       {pvCmd, "show the line of best play from a position"},
+	"verify":   {verifyCmd, "check a database for consistency"},
+	"validate": {validateCmd, "check game records against the rules"},
+	"record":   {recordCmd, "write Board JSON as game records"},
+	"wins":     {winsCmd, "list the longest forced wins of each table"},
+	"convert":  {convertCmd, "convert between v1, v2 and v3 databases"},
+	"engine":   {engineCmd, "speak the Teeko Engine Protocol on standard input and output"},
+	"bench":    {benchCmd, "compare rank query methods"},
+	"bot":      {botCmd, "pick a move for a position as the bot would"},
+	"rooms":    {roomsCmd, "serve multiplayer rooms over WebSockets"},
+	"selfplay": {selfplayCmd, "measure bot strength by playing seeded games"},
+	"serve":    {serveCmd, "serve analysis over HTTP"},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", os.Args[0])
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].usage)
 	}
-
-	fmt.Printf("  Compressed %d -> %d positions (%.1fx)\n",
-		totalFull, totalCanonical, float64(totalFull)/float64(totalCanonical))
-	fmt.Printf("  Compression completed in %v\n", time.Since(start).Round(time.Millisecond))
 }
 
-// Negamax with alpha-beta pruning for heuristic evaluation
-// mover = pieces of player to move, other = opponent pieces
-// Returns score from mover's perspective
-func negamax(mover, other uint32, depth, alpha, beta int) int {
-	if depth == 0 {
-		return evalPosition(mover, other) - evalPosition(other, mover)
-	}
-
-	occupied := mover | other
-	// Try moves in priority order (best destinations first)
-	for pri := 4; pri >= 0; pri-- {
-		destMask := priorityMasks[pri] &^ occupied
-		for p := mover; p != 0; {
-			piece := p & -p
-			p ^= piece
-			pos := bits.TrailingZeros32(piece)
-			newMover := mover ^ piece
-			for dests := neighs[pos] & destMask; dests != 0; {
-				dest := dests & -dests
-				dests ^= dest
-				score := -negamax(other, newMover|dest, depth-1, -beta, -alpha)
-				if score > alpha {
-					alpha = score
-				}
-				if alpha >= beta {
-					return alpha
-				}
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+	cmd.run(os.Args[2:])
+}
+
+// Flag set for a subcommand, with the common -db flag
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	return fs, fs.String("db", "teeko.db", "database file")
+}
+
+// Flags selecting a position, shared by commands that take one;
+// the returned function reports invalid positions with their usage
+// and exits with status 2, as the flag package does
+func positionFlags(fs *flag.FlagSet) func() teeko.Board {
+	pos := fs.String("pos", "", "position string such as \"AAA../BB.../...../...../..... b drop\",\nor - to read a printed board from standard input")
+	a := fs.Uint("a", 0, "squares held by A (bitset), instead of -pos")
+	b := fs.Uint("b", 0, "squares held by B (bitset), instead of -pos")
+	turn := fs.String("turn", "a", "player to move (a or b), with -a and -b")
+	return func() teeko.Board {
+		var board teeko.Board
+		var err error
+		switch *pos {
+		case "":
+			phase := teeko.PhaseDrop
+			if bits.OnesCount32(uint32(*a))+bits.OnesCount32(uint32(*b)) == 8 {
+				phase = teeko.PhaseMove
 			}
-		}
-	}
-	return alpha
-}
-
-// Heuristic evaluation for drawn positions with 4-ply alpha-beta search
-// Returns a score from -80 to +80 based on positional advantage
-func heuristic(a, b uint32) int8 {
-	score := negamax(a, b, 4, -1000, 1000)
-	if score > int(ScoreHeuristicMax) {
-		return ScoreHeuristicMax
-	}
-	if score < -int(ScoreHeuristicMax) {
-		return -ScoreHeuristicMax
-	}
-	return int8(score)
-}
-
-// Precomputed: for each square, which patterns include it
-var squarePatterns [25][]uint32
-
-// Precomputed: central squares bitmask
-const centralSquares = uint32(0b0000001110011100111000000)
-
-func init() {
-	for sq := 0; sq < 25; sq++ {
-		bit := uint32(1) << sq
-		for _, pattern := range winPatterns {
-			if pattern&bit != 0 {
-				squarePatterns[sq] = append(squarePatterns[sq], pattern)
+			board, err = teeko.NewPosition(uint32(*a), uint32(*b), *turn, phase)
+		case "-":
+			text, readErr := io.ReadAll(os.Stdin)
+			if readErr != nil {
+				log.Fatal(readErr)
 			}
+			board, err = teeko.ParsePosition(string(text))
+		default:
+			board, err = teeko.ParsePosition(*pos)
 		}
-	}
-}
-
-// Evaluate how good a position is for the player with pieces 'mine'
-func evalPosition(mine, theirs uint32) int {
-	score := 0
-	occupied := mine | theirs
-
-	// Track which patterns we've already scored
-	var scored uint64
-
-	// Only check patterns that include at least one of my pieces
-	for m := mine; m != 0; {
-		sq := bits.TrailingZeros32(m)
-		m &= m - 1
-		for i, pattern := range squarePatterns[sq] {
-			idx := uint64(sq*16 + i) // Unique index for this (square, pattern) pair
-			if scored&(1<<idx) != 0 {
-				continue
-			}
-			scored |= 1 << idx
-
-			// Skip patterns blocked by opponent
-			if pattern&theirs != 0 {
-				continue
-			}
-			myPieces := bits.OnesCount32(pattern & mine)
-			switch myPieces {
-			case 4:
-				score += 100
-			case 3:
-				if bits.OnesCount32(pattern&^occupied) == 1 {
-					score += 20
-				} else {
-					score += 8
-				}
-			case 2:
-				score += 2
-			case 1:
-				score += 1
-			}
+		if err != nil {
+			fmt.Fprintln(fs.Output(), err)
+			fs.Usage()
+			os.Exit(2)
 		}
+		return board
 	}
-
-	score += bits.OnesCount32(mine&centralSquares) * 2
-	return score
 }
 
-// Score tables (v1: full, v2: canonical only)
-var (
-	scores [9][]int8
-)
-
-// Symmetry reduction constants
-const BLOCK_SIZE = 1024
-
-// Canonical position data (for v2 format)
-var (
-	checkpoints     [9][]int   // checkpoints[n][i] = rank at i*BLOCK_SIZE
-	canonicalScores [9][]int8  // dense array of canonical scores only
-	canonicalCounts [9]int     // count of canonical positions per piece count
-)
-
-// Precomputed positions (a, b pairs) for each piece count
-var (
-	posCache      [9][]uint64 // packed as (a << 32) | b
-	posCacheReady bool
-)
-
-func init() {
-	// Pascal's triangle
-	for n := range 32 {
-		choose[n][0], choose[n][n] = 1, 1
-		for k := 1; k < n; k++ {
-			choose[n][k] = choose[n-1][k-1] + choose[n-1][k]
-		}
-	}
-
-	for i := range 9 {
-		configs[i] = patterns[i] * positions[i]
-	}
-
-	// Allocate score tables
-	for i := range 9 {
-		scores[i] = make([]int8, configs[i])
+// Name of the player to move
+func turnName(board teeko.Board) string {
+	if len(board.M)%2 == 0 {
+		return "a"
 	}
+	return "b"
 }
 
-// Goedel numbering - bijection between positions and integers
-func goedel(a, b uint32, n int) int {
-	if n == 0 {
-		return 0
-	}
-	ab := a | b
-	posNum, patNum := 0, 0
-	pat := uint32(0)
-	patBit := uint32(1 << (n - 1))
-	nRed := (n + 1) / 2
-
-	for j := range Size {
-		remaining := bits.OnesCount32(ab >> j)
-		if ab&(1<<j) != 0 {
-			remaining--
-			if b&(1<<j) != 0 {
-				pat |= patBit
-			}
-			patBit >>= 1
-			posNum += choose[Size-j-1][remaining+1]
-		}
-	}
-
-	for j := range n {
-		if pat&(1<<j) != 0 {
-			nRed--
-			patNum += choose[n-j-1][nRed+1]
-		}
-	}
-
-	return posNum + positions[n]*patNum
-}
-
-func degoedel(idx, n int) (a, b uint32) {
-	if n == 0 {
-		return 0, 0
-	}
-	patNum := idx / positions[n]
-	posNum := idx % positions[n]
-
-	// Decode pattern
-	patWalk := patterns[n]
-	pat := uint32(0)
-	nRed := (n + 1) / 2
-	for j := range n {
-		pcs := n - j
-		temp := (patWalk * (pcs - nRed)) / pcs
-		if patNum >= temp {
-			patNum -= temp
-			patWalk = (patWalk * nRed) / pcs
-			nRed--
-			pat |= 1 << j
-		} else {
-			patWalk = temp
-		}
-	}
-
-	// Decode position
-	posWalk := positions[n]
-	pcs := n
-	patBit := uint32(1 << (n - 1))
-	for j := range Size {
-		locs := Size - j
-		temp := (posWalk * (locs - pcs)) / locs
-		if posNum >= temp {
-			posNum -= temp
-			posWalk = (posWalk * pcs) / locs
-			pcs--
-			if pat&patBit != 0 {
-				b |= 1 << j
-			} else {
-				a |= 1 << j
-			}
-			patBit >>= 1
-		} else {
-			posWalk = temp
-		}
+func openDB(path string) *teeko.DB {
+	db, err := teeko.OpenDB(path)
+	if err != nil {
+		log.Fatal(err)
 	}
-	return
-}
-
-func isWin(mask uint32) bool {
-	return wins[mask]
+	return db
 }
 
-// Pack/unpack position pairs
-func packPos(a, b uint32) uint64 {
-	return (uint64(a) << 32) | uint64(b)
+func printWin(label string, w *teeko.Win) {
+	board := teeko.TableBoard(w.A, w.B, w.Pieces)
+	fmt.Printf("\nLongest forced win for %s:\n", label)
+	fmt.Printf("  Distance: %d plies\n", w.Distance())
+	fmt.Printf("  Pieces: %d\n", w.Pieces)
+	fmt.Printf("  A squares: %s\n", strings.Join(teeko.SquareNames(board.A), " "))
+	fmt.Printf("  B squares: %s\n", strings.Join(teeko.SquareNames(board.B), " "))
+	printPosition(board)
 }
 
-func unpackPos(packed uint64) (a, b uint32) {
-	return uint32(packed >> 32), uint32(packed)
+// Describe a move score from the mover's perspective
+func describeScore(s int8) string {
+	d, ok := teeko.Distance(s)
+	return describeOutcome(s, d, ok)
 }
 
-// Initialize position cache for faster lookups
-func initPosCache() {
-	if posCacheReady {
-		return
-	}
-	fmt.Println("Precomputing position cache…")
-	start := time.Now()
-
-	numWorkers := runtime.NumCPU()
-	var wg sync.WaitGroup
-
-	for n := range 9 {
-		posCache[n] = make([]uint64, configs[n])
-		chunkSize := (configs[n] + numWorkers - 1) / numWorkers
-
-		for w := 0; w < numWorkers; w++ {
-			wg.Add(1)
-			start := w * chunkSize
-			end := min(start+chunkSize, configs[n])
-			go func(n, start, end int) {
-				defer wg.Done()
-				for g := start; g < end; g++ {
-					a, b := degoedel(g, n)
-					posCache[n][g] = packPos(a, b)
-				}
-			}(n, start, end)
-		}
-		wg.Wait()
+// Describe a position score from the mover's perspective
+func describePosition(s int8) string {
+	d, ok := teeko.PositionDistance(s)
+	if ok && d == 0 {
+		return "lost"
 	}
-
-	posCacheReady = true
-	fmt.Printf("  Position cache ready in %v\n", time.Since(start).Round(time.Millisecond))
-}
-
-// Fast position lookup from cache
-func getPos(g, n int) (a, b uint32) {
-	return unpackPos(posCache[n][g])
+	return describeOutcome(s, d, ok)
 }
 
-// Score combination for minimax
-func bestScore(neighbors []int, table []int8) int8 {
-	result := ScoreNone
-	for _, n := range neighbors {
-		s := table[n]
-		if s == ScoreNone {
-			s = ScoreTie
-		} else if s < ScoreBWin || s > ScoreAWin {
-			continue
-		}
-		s = -s // opponent's perspective
-		// Only decay win/loss scores, not heuristics
-		if s > ScoreHeuristicMax {
-			s--
-		} else if s < -ScoreHeuristicMax {
-			s++
-		}
-		if result == ScoreNone || s > result {
-			result = s
-		}
-	}
-	if result == ScoreNone {
-		return ScoreTie
+func describeOutcome(s int8, d int, forced bool) string {
+	if s == teeko.ScoreNone {
+		return "unknown"
 	}
-	return result
-}
-
-// Database computation
-func computePlay() {
-	fmt.Println("Computing play phase…")
-	start := time.Now()
-
-	// Precompute positions first
-	initPosCache()
-
-	table := scores[8]
-	numWorkers := runtime.NumCPU()
-	fmt.Printf("  Using %d workers\n", numWorkers)
-
-	// Initial scores (parallel)
-	fmt.Printf("  Initializing %d positions…\n", configs[8])
-	var illegal, aWins, bWins atomic.Int64
-	var wg sync.WaitGroup
-	chunkSize := (configs[8] + numWorkers - 1) / numWorkers
-
-	for w := 0; w < numWorkers; w++ {
-		wg.Add(1)
-		startIdx := w * chunkSize
-		endIdx := min(startIdx+chunkSize, configs[8])
-		go func(startIdx, endIdx int) {
-			defer wg.Done()
-			localIllegal, localAWins, localBWins := int64(0), int64(0), int64(0)
-			for g := startIdx; g < endIdx; g++ {
-				a, b := getPos(g, 8)
-				aWin, bWin := isWin(a), isWin(b)
-				switch {
-				case aWin && bWin:
-					table[g] = ScoreIllegal
-					localIllegal++
-				case bWin:
-					table[g] = ScoreBWin
-					localBWins++
-				case aWin:
-					table[g] = ScoreAWin
-					localAWins++
-				default:
-					table[g] = ScoreTie
-				}
-			}
-			illegal.Add(localIllegal)
-			aWins.Add(localAWins)
-			bWins.Add(localBWins)
-		}(startIdx, endIdx)
-	}
-	wg.Wait()
-	fmt.Printf("  Initial: %d illegal, %d A wins, %d B wins\n", illegal.Load(), aWins.Load(), bWins.Load())
-
-	// Retrograde analysis
-	fmt.Println("  Retrograde analysis…")
-	snapshot := make([]int8, configs[8])
-	maxLevel := 0
-
-	for level := ScoreAWin; level > 0; level-- {
-		copy(snapshot, table)
-		var changed atomic.Bool
-
-		// Phase 1: Generate unmoves (parallel)
-		for w := 0; w < numWorkers; w++ {
-			wg.Add(1)
-			startIdx := w * chunkSize
-			endIdx := min(startIdx+chunkSize, configs[8])
-			go func(startIdx, endIdx int) {
-				defer wg.Done()
-				for g := startIdx; g < endIdx; g++ {
-					if snapshot[g] == ScoreTie {
-						continue
-					}
-					ps := -snapshot[g]
-					a, b := getPos(g, 8)
-
-					// Generate unmoves (predecessor positions)
-					ab := a | b
-					for p := b; p != 0; {
-						piece := p & -p
-						p ^= piece
-						pos := bits.TrailingZeros32(piece)
-						for dests := neighs[pos] &^ ab; dests != 0; {
-							dest := dests & -dests
-							dests ^= dest
-							n := goedel((b^piece)|dest, a, 8)
-							if ps == level {
-								newscore := ps - 1
-								if psn := snapshot[n]; (psn < newscore && psn > ScoreBWin) || psn == ScoreNone {
-									table[n] = newscore
-									changed.Store(true)
-								}
-							} else if ps == -level && snapshot[n] == ScoreTie {
-								snapshot[n] = ScoreNone
-							}
-						}
-					}
-				}
-			}(startIdx, endIdx)
-		}
-		wg.Wait()
-
-		// Phase 2: Process marked positions (parallel)
-		for w := 0; w < numWorkers; w++ {
-			wg.Add(1)
-			startIdx := w * chunkSize
-			endIdx := min(startIdx+chunkSize, configs[8])
-			go func(startIdx, endIdx int) {
-				defer wg.Done()
-				neighbors := make([]int, 0, 32) // pre-allocated per worker
-				for g := startIdx; g < endIdx; g++ {
-					if snapshot[g] == ScoreNone {
-						a, b := getPos(g, 8)
-						neighbors = neighbors[:0] // reset without allocation
-						ab := a | b
-						for p := a; p != 0; {
-							piece := p & -p
-							p ^= piece
-							pos := bits.TrailingZeros32(piece)
-							for dests := neighs[pos] &^ ab; dests != 0; {
-								dest := dests & -dests
-								dests ^= dest
-								neighbors = append(neighbors, goedel(b, (a^piece)|dest, 8))
-							}
-						}
-						if ns := bestScore(neighbors, snapshot); ns != ScoreTie && ns != ScoreNone {
-							table[g] = ns
-							changed.Store(true)
-						} else {
-							table[g] = ScoreTie
-						}
-					}
-				}
-			}(startIdx, endIdx)
-		}
-		wg.Wait()
-
-		if !changed.Load() {
-			maxLevel = int(ScoreAWin - level)
-			fmt.Printf("  Converged at depth %d\n", maxLevel)
-			break
+	if forced {
+		if s > 0 {
+			return fmt.Sprintf("win in %d", d)
 		}
-		if level%10 == 0 {
-			fmt.Printf("    Level %d…\n", level)
-		}
-	}
-
-	// Compute heuristics for drawn positions (parallel)
-	fmt.Println("  Computing heuristics for draws…")
-	var draws, progress atomic.Int64
-	// Count total draws first
-	var totalDraws int64
-	for g := 0; g < configs[8]; g++ {
-		if table[g] == ScoreTie {
-			totalDraws++
-		}
-	}
-	fmt.Printf("    0%% (0/%d)\r", totalDraws)
-	for w := 0; w < numWorkers; w++ {
-		wg.Add(1)
-		startIdx := w * chunkSize
-		endIdx := min(startIdx+chunkSize, configs[8])
-		go func(startIdx, endIdx int) {
-			defer wg.Done()
-			localDraws := int64(0)
-			for g := startIdx; g < endIdx; g++ {
-				if table[g] == ScoreTie {
-					a, b := getPos(g, 8)
-					table[g] = heuristic(a, b)
-					localDraws++
-					if localDraws%1000 == 0 {
-						cur := progress.Add(1000)
-						pct := cur * 100 / totalDraws
-						fmt.Printf("    %d%% (%d/%d)\r", pct, cur, totalDraws)
-					}
-				}
-			}
-			draws.Add(localDraws)
-			progress.Add(localDraws % 1000)
-		}(startIdx, endIdx)
-	}
-	wg.Wait()
-	fmt.Printf("    100%% (%d/%d)\n", draws.Load(), totalDraws)
-
-	countStats("Play", table)
-	fmt.Printf("  Play phase completed in %v\n", time.Since(start).Round(time.Millisecond))
-}
-
-func computeDrop() {
-	fmt.Println("Computing drop phase…")
-	start := time.Now()
-	numWorkers := runtime.NumCPU()
-
-	// 7 pieces - check for B wins, then propagate from play
-	fmt.Printf("  Processing 7 pieces (%d positions)…\n", configs[7])
-	table7 := scores[7]
-	var bWins atomic.Int64
-	var wg sync.WaitGroup
-	chunkSize := (configs[7] + numWorkers - 1) / numWorkers
-
-	for w := 0; w < numWorkers; w++ {
-		wg.Add(1)
-		startIdx := w * chunkSize
-		endIdx := min(startIdx+chunkSize, configs[7])
-		go func(startIdx, endIdx int) {
-			defer wg.Done()
-			localBWins := int64(0)
-			for g := startIdx; g < endIdx; g++ {
-				_, b := getPos(g, 7)
-				if isWin(b) {
-					table7[g] = ScoreBWin
-					localBWins++
-				}
-			}
-			bWins.Add(localBWins)
-		}(startIdx, endIdx)
+		return fmt.Sprintf("loss in %d", d)
 	}
-	wg.Wait()
-	fmt.Printf("    %d immediate B wins\n", bWins.Load())
-	propagateDrop(7, numWorkers)
-
-	// 6 to 0 pieces
-	for n := 6; n >= 0; n-- {
-		fmt.Printf("  Processing %d pieces (%d positions)…\n", n, configs[n])
-		propagateDrop(n, numWorkers)
-	}
-
-	fmt.Printf("  Initial position score: %d\n", scores[0][0])
-	fmt.Printf("  Drop phase completed in %v\n", time.Since(start).Round(time.Millisecond))
+	return fmt.Sprintf("draw (%+d)", s)
 }
 
-func propagateDrop(n, numWorkers int) {
-	current, next := scores[n], scores[n+1]
-	var wg sync.WaitGroup
-	chunkSize := (configs[n] + numWorkers - 1) / numWorkers
-
-	for w := 0; w < numWorkers; w++ {
-		wg.Add(1)
-		startIdx := w * chunkSize
-		endIdx := min(startIdx+chunkSize, configs[n])
-		go func(startIdx, endIdx int) {
-			defer wg.Done()
-			neighbors := make([]int, 0, Size) // pre-allocated per worker
-			for g := startIdx; g < endIdx; g++ {
-				if current[g] != ScoreTie {
-					continue // preserve wins detected earlier
-				}
-				a, b := getPos(g, n)
-				ab := a | b
-
-				neighbors = neighbors[:0] // reset without allocation
-				for sq := uint32(1); sq < (1 << Size); sq <<= 1 {
-					if sq&ab == 0 {
-						neighbors = append(neighbors, goedel(b, a|sq, n+1))
-					}
-				}
-				current[g] = bestScore(neighbors, next)
-			}
-		}(startIdx, endIdx)
-	}
-	wg.Wait()
-	countStats(fmt.Sprintf("Drop %d", n), current)
+// Print a board with its position string
+func printPosition(board teeko.Board) {
+	printBoard(board.A, board.B)
+	fmt.Printf("  Position: %s\n", teeko.FormatPosition(board))
 }
 
-func countStats(label string, table []int8) {
-	ties, aWins, bWins, aAdvantage, bAdvantage := 0, 0, 0, 0, 0
-	for _, s := range table {
-		switch {
-		case s == ScoreTie:
-			ties++
-		case s > ScoreHeuristicMax:
-			aWins++
-		case s < -ScoreHeuristicMax:
-			bWins++
-		case s > 0:
-			aAdvantage++
-		case s < 0:
-			bAdvantage++
-		}
-	}
-	fmt.Printf("  %s: %d draws (%d A+, %d even, %d B+), %d A wins, %d B wins\n",
-		label, aAdvantage+ties+bAdvantage, aAdvantage, ties, bAdvantage, aWins, bWins)
+func printBoard(a, b uint32) {
+	fmt.Println("  Board:")
+	writeBoard(os.Stdout, a, b, boardStyle{})
 }
 
-func maskToSquares(m uint32) []int {
-	var sq []int
-	for i := range Size {
-		if m&(1<<i) != 0 {
-			sq = append(sq, i)
-		}
-	}
-	return sq
+// How writeBoard decorates the grid
+type boardStyle struct {
+	color bool   // ANSI colours: blue for A, red for B as in the web UI
+	mark  uint32 // squares shown in bold, such as the last action's
 }
 
-func findLongestWins() {
-	fmt.Println("Finding longest forced wins…")
-
-	var longestAWin, longestBWin int8
-	var longestAIdx, longestBIdx, longestAPieces, longestBPieces int
-
-	longestAWin = ScoreAWin // Start at shortest (126), look for smallest positive
-	longestBWin = ScoreBWin // Start at shortest (-126), look for largest negative
-
-	for n := range 9 {
-		table := scores[n]
-		for g, s := range table {
-			// A win: smaller score = longer win (96 is longest forced win)
-			if s > ScoreHeuristicMax && s <= ScoreAWin && s < longestAWin {
-				longestAWin = s
-				longestAIdx = g
-				longestAPieces = n
-			}
-			// B win: larger score (closer to 0) = longer win (-96 is longest)
-			if s < -ScoreHeuristicMax && s >= ScoreBWin && s > longestBWin {
-				longestBWin = s
-				longestBIdx = g
-				longestBPieces = n
-			}
-		}
-	}
-
-	if longestAWin <= ScoreAWin && longestAWin > ScoreHeuristicMax {
-		a, b := degoedel(longestAIdx, longestAPieces)
-		dist := int(ScoreAWin - longestAWin)
-		fmt.Printf("\nLongest forced win for Blue (A):\n")
-		fmt.Printf("  Distance: %d plies\n", dist)
-		fmt.Printf("  Pieces: %d\n", longestAPieces)
-		fmt.Printf("  A squares: %v\n", maskToSquares(a))
-		fmt.Printf("  B squares: %v\n", maskToSquares(b))
-		printBoard(a, b)
-	}
-
-	if longestBWin >= ScoreBWin && longestBWin < -ScoreHeuristicMax {
-		a, b := degoedel(longestBIdx, longestBPieces)
-		dist := int(longestBWin - ScoreBWin)
-		fmt.Printf("\nLongest forced win for Red (B):\n")
-		fmt.Printf("  Distance: %d plies\n", dist)
-		fmt.Printf("  Pieces: %d\n", longestBPieces)
-		fmt.Printf("  A squares: %v\n", maskToSquares(a))
-		fmt.Printf("  B squares: %v\n", maskToSquares(b))
-		printBoard(a, b)
-	}
-}
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiBlue  = "\x1b[34m"
+	ansiRed   = "\x1b[31m"
+	ansiDim   = "\x1b[2m"
+)
 
-func printBoard(a, b uint32) {
-	fmt.Println("  Board:")
-	for row := 0; row < Edge; row++ {
-		fmt.Print("    ")
-		for col := 0; col < Edge; col++ {
-			sq := uint32(1) << (row*Edge + col)
+// Write the grid with row labels 5 to 1 and column labels a to e
+func writeBoard(w io.Writer, a, b uint32, style boardStyle) {
+	for row := 0; row < teeko.Edge; row++ {
+		fmt.Fprintf(w, "    %d ", teeko.Edge-row)
+		for col := 0; col < teeko.Edge; col++ {
+			sq := uint32(1) << (row*teeko.Edge + col)
+			cell, color := "·", ansiDim
 			switch {
 			case a&sq != 0:
-				fmt.Print("A ")
+				cell, color = "A", ansiBlue
 			case b&sq != 0:
-				fmt.Print("B ")
-			default:
-				fmt.Print("· ")
+				cell, color = "B", ansiRed
 			}
+			if style.color {
+				if style.mark&sq != 0 {
+					color += ansiBold
+				}
+				cell = color + cell + ansiReset
+			}
+			fmt.Fprint(w, cell+" ")
 		}
-		fmt.Println()
-	}
-}
-
-// Database I/O
-const dbMagic = "TEEK"
-const dbVersionV1 = uint32(1)
-const dbVersionV2 = uint32(2)
-
-func saveDB(filename string) error {
-	f, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	f.Write([]byte(dbMagic))
-	binary.Write(f, binary.LittleEndian, dbVersionV2)
-
-	for n := range 9 {
-		// Write canonical count
-		binary.Write(f, binary.LittleEndian, uint32(canonicalCounts[n]))
-		// Write number of checkpoints
-		binary.Write(f, binary.LittleEndian, uint32(len(checkpoints[n])))
-		// Write checkpoints
-		for _, cp := range checkpoints[n] {
-			binary.Write(f, binary.LittleEndian, uint32(cp))
-		}
-		// Write scores
-		buf := make([]byte, len(canonicalScores[n]))
-		for i, s := range canonicalScores[n] {
-			buf[i] = byte(s)
-		}
-		f.Write(buf)
-	}
-	return nil
-}
-func main() {
-	dbFile := flag.String("db", "teeko.db", "database file")
-	flag.Parse()
-
-	computePlay()
-	computeDrop()
-	findLongestWins()
-	compressScores()
-	fmt.Printf("Saving %s…\n", *dbFile)
-	if err := saveDB(*dbFile); err != nil {
-		log.Fatal(err)
+		fmt.Fprintln(w)
 	}
+	fmt.Fprintln(w, "      a b c d e")
 }
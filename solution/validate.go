@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pcarrier/teeko.cc/record"
+)
+
+// Check game records against the rules
+func validateCmd(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the final board of each game as Board JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: validate [-json] [record file…] (standard input without files)")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	invalid := 0
+	for _, path := range files {
+		invalid += validateFile(path, *asJSON)
+	}
+	if invalid > 0 {
+		log.Fatalf("%d invalid games", invalid)
+	}
+}
+
+// Validate the games of a file, returning how many are invalid
+func validateFile(path string, asJSON bool) int {
+	in, closeInput := openInput(path)
+	defer closeInput()
+
+	rd := record.NewReader(in)
+	invalid := 0
+	for i := 1; ; i++ {
+		rec, err := rd.Read()
+		if err == io.EOF {
+			return invalid
+		}
+		if err != nil {
+			// Syntax errors leave the rest of the file unreadable
+			fmt.Printf("%s: game %d: %v\n", path, i, err)
+			return invalid + 1
+		}
+		if err := rec.Validate(); err != nil {
+			fmt.Printf("%s: game %d: %v\n", path, i, err)
+			invalid++
+			continue
+		}
+		if asJSON {
+			board, _ := rec.Board()
+			out, _ := json.Marshal(board)
+			fmt.Println(string(out))
+		} else {
+			fmt.Printf("%s: game %d: %d actions, %s\n", path, i, len(rec.Actions), rec.Get(record.TagResult))
+		}
+	}
+}
+
+// Open a file, or standard input for "-"
+func openInput(path string) (io.Reader, func()) {
+	if path == "-" {
+		return os.Stdin, func() {}
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return f, func() { f.Close() }
+}
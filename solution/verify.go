@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// Check a database for consistency
+func verifyCmd(args []string) {
+	fs, dbFile := newFlagSet("verify")
+	fs.Parse(args)
+	db := openDB(*dbFile)
+
+	fmt.Println("Checking canonical index…")
+	if err := db.CheckIndex(); err != nil {
+		fmt.Printf("  %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("  OK")
+
+	fmt.Println("Re-deriving scores from successors…")
+	mismatches := db.Verify(func(m teeko.Mismatch) {
+		fmt.Printf("\n  %d pieces: stored %d, expected %d\n", m.Pieces, m.Stored, m.Expected)
+		printBoard(m.A, m.B)
+	})
+	if mismatches > 0 {
+		fmt.Printf("%d mismatches\n", mismatches)
+		os.Exit(1)
+	}
+	fmt.Println("  OK")
+}
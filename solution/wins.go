@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pcarrier/teeko.cc/teeko"
+)
+
+// One of the longest forced wins of a table
+type longWin struct {
+	Pieces   int      `json:"pieces"`
+	Side     string   `json:"side"` // a when the player to move wins, b when the opponent does
+	Distance int      `json:"distance"`
+	Score    int8     `json:"score"`
+	Position string   `json:"position"`
+	Line     []string `json:"line,omitempty"`
+}
+
+// Distance histogram of a table, indexed by plies to the win
+type winHistogram struct {
+	Pieces int   `json:"pieces"`
+	A      []int `json:"a"` // player to move wins
+	B      []int `json:"b"` // opponent wins
+}
+
+// List the longest forced wins of each table and the distribution of win distances
+func winsCmd(args []string) {
+	fs, dbFile := newFlagSet("wins")
+	k := fs.Int("k", 10, "wins to list per piece count and side")
+	pieces := fs.String("pieces", "", "comma-separated piece counts, 8 for the move phase (default all)")
+	lines := fs.Bool("lines", true, "show the full winning lines")
+	asJSON := fs.Bool("json", false, "print the wins as JSON")
+	hist := fs.String("hist", "", "write distance histograms to this file, as CSV or, for .json, JSON")
+	fs.Parse(args)
+
+	counts := []int{0, 1, 2, 3, 4, 5, 6, 7, 8}
+	if *pieces != "" {
+		counts = counts[:0]
+		for _, f := range strings.Split(*pieces, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(f))
+			if err != nil || n < 0 || n > 8 {
+				log.Fatalf("bad piece count %q", f)
+			}
+			counts = append(counts, n)
+		}
+	}
+
+	db := openDB(*dbFile)
+	if !db.Indexed() {
+		fmt.Fprintln(os.Stderr, "Indexing…")
+		db.BuildIndex()
+	}
+
+	var wins []longWin
+	var histograms []winHistogram
+	for _, n := range counts {
+		a, b := db.DistanceHistogram(n)
+		histograms = append(histograms, winHistogram{n, a, b})
+		aWins, bWins := db.LongestWinsIn(n, *k)
+		for _, side := range []struct {
+			name string
+			wins []teeko.Win
+		}{{"a", aWins}, {"b", bWins}} {
+			for _, w := range side.wins {
+				board := teeko.TableBoard(w.A, w.B, n)
+				lw := longWin{Pieces: n, Side: side.name, Distance: w.Distance(), Score: w.Score, Position: teeko.FormatPosition(board)}
+				if *lines {
+					for _, p := range teeko.PrincipalVariation(db, board, 0) {
+						lw.Line = append(lw.Line, p.Action.String())
+					}
+				}
+				wins = append(wins, lw)
+			}
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(wins); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		printLongWins(wins, histograms)
+	}
+	if *hist != "" {
+		if err := writeHistograms(*hist, histograms); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func printLongWins(wins []longWin, histograms []winHistogram) {
+	total := func(counts []int) int {
+		sum := 0
+		for _, c := range counts[1:] {
+			sum += c
+		}
+		return sum
+	}
+	i := 0
+	for _, h := range histograms {
+		for _, side := range []struct {
+			name, label string
+			counts      []int
+		}{{"a", "player to move wins", h.A}, {"b", "player to move loses", h.B}} {
+			fmt.Printf("\n%d pieces, %s (%d forced):\n", h.Pieces, side.label, total(side.counts))
+			for rank := 1; i < len(wins) && wins[i].Pieces == h.Pieces && wins[i].Side == side.name; i, rank = i+1, rank+1 {
+				w := wins[i]
+				fmt.Printf("  %2d. %2d plies  %s\n", rank, w.Distance, w.Position)
+				if len(w.Line) > 0 {
+					fmt.Printf("      %s\n", strings.Join(w.Line, " "))
+				}
+			}
+		}
+	}
+}
+
+// Write histograms as JSON, or as CSV rows of pieces, distance and the count of each side
+func writeHistograms(path string, histograms []winHistogram) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".json") {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(histograms); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"pieces", "distance", "a", "b"})
+	for _, h := range histograms {
+		for d := range h.A {
+			if h.A[d] == 0 && h.B[d] == 0 {
+				continue
+			}
+			w.Write([]string{strconv.Itoa(h.Pieces), strconv.Itoa(d), strconv.Itoa(h.A[d]), strconv.Itoa(h.B[d])})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,151 @@
+// Package teeko implements the rules of Teeko, Goedel numbering of positions,
+// D4 symmetry reduction and a retrograde solver for the complete game.
+// Original algorithm by Guy L. Steele Jr. (1998-2000)
+package teeko
+
+import "math/bits"
+
+// Board constants
+const (
+	Edge = 5
+	Size = Edge * Edge
+)
+
+// Score values
+const (
+	ScoreTie     int8 = 0
+	ScoreBWin    int8 = -126
+	ScoreAWin    int8 = 126
+	ScoreNone    int8 = -127
+	ScoreIllegal int8 = -128
+	// Heuristic scores for drawn positions (range -80 to +80)
+	// ±81 to ±126 are reserved for forced wins (up to 45 moves)
+	ScoreHeuristicMax int8 = 80
+)
+
+// Precomputed values
+var (
+	choose [32][32]int
+	// Patterns[n] is the number of ways to colour n pieces (B, who just moved, gets the extra one when odd)
+	Patterns = [9]int{1, 1, 2, 3, 6, 10, 20, 35, 70}
+	// Positions[n] is the number of ways to place n pieces on the board
+	Positions = [9]int{1, 25, 300, 2300, 12650, 53130, 177100, 480700, 1081575}
+	// Configs[n] is the number of Goedel indices for n pieces
+	Configs [9]int
+)
+
+// Precomputed neighbor masks for each position (same as NEIGHS_BY_POSITION in model.ts)
+var Neighs = [Size]uint32{
+	98, 229, 458, 916, 776, 3139, 7335, 14670, 29340, 24856, 100448, 234720,
+	469440, 938880, 795392, 3214336, 7511040, 15022080, 30044160, 25452544,
+	2195456, 5472256, 10944512, 21889024, 9175040,
+}
+
+// Winning positions (same as WINNING_POSITIONS in model.ts)
+var wins = map[uint32]bool{
+	99: true, 198: true, 396: true, 792: true, 3168: true, 6336: true, 12672: true, 25344: true,
+	101376: true, 202752: true, 405504: true, 811008: true, 3244032: true, 6488064: true,
+	12976128: true, 25952256: true, 15: true, 30: true, 480: true, 960: true, 15360: true,
+	30720: true, 491520: true, 983040: true, 15728640: true, 31457280: true, 33825: true,
+	67650: true, 135300: true, 270600: true, 541200: true, 1082400: true, 2164800: true,
+	4329600: true, 8659200: true, 17318400: true, 266305: true, 532610: true, 8521760: true,
+	17043520: true, 34952: true, 69904: true, 1118464: true, 2236928: true,
+}
+
+// Winning patterns as slice for iteration
+var WinPatterns = []uint32{
+	99, 198, 396, 792, 3168, 6336, 12672, 25344,
+	101376, 202752, 405504, 811008, 3244032, 6488064,
+	12976128, 25952256, 15, 30, 480, 960, 15360,
+	30720, 491520, 983040, 15728640, 31457280, 33825,
+	67650, 135300, 270600, 541200, 1082400, 2164800,
+	4329600, 8659200, 17318400, 266305, 532610, 8521760,
+	17043520, 34952, 69904, 1118464, 2236928,
+}
+
+func init() {
+	// Pascal's triangle
+	for n := range 32 {
+		choose[n][0], choose[n][n] = 1, 1
+		for k := 1; k < n; k++ {
+			choose[n][k] = choose[n-1][k-1] + choose[n-1][k]
+		}
+	}
+
+	for i := range 9 {
+		Configs[i] = Patterns[i] * Positions[i]
+	}
+}
+
+// IsWin reports whether the pieces in mask form a winning pattern
+func IsWin(mask uint32) bool {
+	return wins[mask]
+}
+
+// Position is a board with A's pieces in A and B's pieces in B (bitsets).
+// By convention A is the player to move when encoding with Goedel.
+type Position struct {
+	A, B uint32
+}
+
+// Pieces returns the number of pieces on the board
+func (p Position) Pieces() int {
+	return bits.OnesCount32(p.A) + bits.OnesCount32(p.B)
+}
+
+// Goedel returns the Goedel index of the position among those with the same piece count
+func (p Position) Goedel() int {
+	return Goedel(p.A, p.B, p.Pieces())
+}
+
+// Canonical returns the smallest Goedel index among the 8 symmetric variants
+func (p Position) Canonical() int {
+	return Canonical(p.A, p.B, p.Pieces())
+}
+
+// Transform applies symmetry sym (index into SymTables) to both sides
+func (p Position) Transform(sym int) Position {
+	return Position{TransformMask(p.A, sym), TransformMask(p.B, sym)}
+}
+
+// Swap exchanges the two sides, giving the position from the opponent's perspective
+func (p Position) Swap() Position {
+	return Position{p.B, p.A}
+}
+
+// PositionFromGoedel decodes Goedel index g for n pieces
+func PositionFromGoedel(g, n int) Position {
+	a, b := Degoedel(g, n)
+	return Position{a, b}
+}
+
+// Distance converts a score into the number of moves to its forced outcome
+// (1 = win/lose this move), like formatScore in bot.ts.
+// ok is false for heuristic scores, which have no forced outcome, and for ScoreNone.
+func Distance(score int8) (moves int, ok bool) {
+	if score < ScoreBWin || score > ScoreAWin {
+		return 0, false
+	} else if score > ScoreHeuristicMax {
+		// Win: 126 = win in 1 move, 125 = win in 2, etc.
+		return 127 - int(score), true
+	} else if score < -ScoreHeuristicMax {
+		// Loss: -126 = lose in 1 move, -125 = lose in 2, etc.
+		return 127 + int(score), true
+	}
+	return 0, false
+}
+
+// PositionDistance converts the score of a position, as stored in the tables, into
+// the number of moves to its forced outcome. Positions score one step closer to 0
+// than their best move: 125 = win in 1 move, -124 = lose in 2, -126 = already lost.
+// Like Distance, ok is false for heuristic scores and ScoreNone.
+func PositionDistance(score int8) (moves int, ok bool) {
+	if score < ScoreBWin || score > ScoreAWin {
+		return 0, false
+	} else if score > ScoreHeuristicMax {
+		return 126 - int(score), true
+	} else if score < -ScoreHeuristicMax {
+		return 126 + int(score), true
+	}
+	return 0, false
+}
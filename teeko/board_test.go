@@ -0,0 +1,28 @@
+package teeko
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	for _, tc := range []struct {
+		score          int8
+		move, position int
+		ok             bool
+	}{
+		{ScoreAWin, 1, 0, true},
+		{125, 2, 1, true},
+		{ScoreHeuristicMax + 1, 46, 45, true},
+		{ScoreHeuristicMax, 0, 0, false},
+		{0, 0, 0, false},
+		{-ScoreHeuristicMax, 0, 0, false},
+		{-124, 3, 2, true},
+		{ScoreBWin, 1, 0, true},
+		{ScoreNone, 0, 0, false},
+	} {
+		if d, ok := Distance(tc.score); d != tc.move || ok != tc.ok {
+			t.Errorf("Distance(%d) = %d, %v, want %d, %v", tc.score, d, ok, tc.move, tc.ok)
+		}
+		if d, ok := PositionDistance(tc.score); d != tc.position || ok != tc.ok {
+			t.Errorf("PositionDistance(%d) = %d, %v, want %d, %v", tc.score, d, ok, tc.position, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package teeko
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Checkpoint file: magic, version, phase, position, then the 8-piece score table
+const checkpointMagic = "TKCP"
+const checkpointVersion = uint32(1)
+
+type playPhase uint32
+
+const (
+	phaseRetrograde playPhase = iota // position is the next level to process
+	phaseHeuristics                  // position is the next Goedel index to evaluate
+	phaseDone                        // play phase complete
+)
+
+type playProgress struct {
+	phase playPhase
+	pos   int
+}
+
+func (p playProgress) String() string {
+	switch p.phase {
+	case phaseRetrograde:
+		return fmt.Sprintf("retrograde level %d", p.pos)
+	case phaseHeuristics:
+		return fmt.Sprintf("heuristics from %d", p.pos)
+	default:
+		return "play phase done"
+	}
+}
+
+// Save progress to CheckpointFile if enabled and CheckpointEvery has elapsed (or force is set).
+// Writes go to a temporary file renamed into place, so a kill never leaves a truncated checkpoint.
+func (s *Solver) checkpoint(p playProgress, force bool) error {
+	if s.CheckpointFile == "" || (!force && time.Since(s.lastCheckpoint) < s.CheckpointEvery) {
+		return nil
+	}
+	start := time.Now()
+	tmp := s.CheckpointFile + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	bw.WriteString(checkpointMagic)
+	binary.Write(bw, binary.LittleEndian, [3]uint32{checkpointVersion, uint32(p.phase), uint32(p.pos)})
+	buf := make([]byte, len(s.Scores[8]))
+	for i, sc := range s.Scores[8] {
+		buf[i] = byte(sc)
+	}
+	bw.Write(buf)
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.CheckpointFile); err != nil {
+		return err
+	}
+	s.lastCheckpoint = time.Now()
+	s.logf("    Checkpoint saved (%s) in %v\n", p, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Resume loads the play phase progress saved in CheckpointFile,
+// so that the next ComputePlay continues where it stopped
+func (s *Solver) Resume() error {
+	f, err := os.Open(s.CheckpointFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+
+	var magic [4]byte
+	var header [3]uint32
+	if _, err := io.ReadFull(br, magic[:]); err != nil || string(magic[:]) != checkpointMagic {
+		return fmt.Errorf("%s: not a solver checkpoint", s.CheckpointFile)
+	}
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("%s: %w", s.CheckpointFile, err)
+	}
+	if header[0] != checkpointVersion {
+		return fmt.Errorf("%s: unsupported checkpoint version %d", s.CheckpointFile, header[0])
+	}
+	p := &playProgress{playPhase(header[1]), int(header[2])}
+	switch {
+	case p.phase > phaseDone,
+		p.phase == phaseRetrograde && (p.pos < 0 || p.pos > int(ScoreAWin)),
+		p.phase == phaseHeuristics && p.pos > len(s.Scores[8]):
+		return fmt.Errorf("%s: invalid progress %d/%d", s.CheckpointFile, header[1], header[2])
+	}
+
+	// Full and canonical solvers have different table sizes
+	buf := make([]byte, len(s.Scores[8]))
+	if _, err := io.ReadFull(br, buf); err != nil {
+		return fmt.Errorf("%s: reading scores: %w", s.CheckpointFile, err)
+	}
+	if err := expectEOF(br); err != nil {
+		return fmt.Errorf("%s: checkpoint is from a different kind of solver", s.CheckpointFile)
+	}
+	for i, b := range buf {
+		s.Scores[8][i] = int8(b)
+	}
+	s.resumed = p
+	return nil
+}
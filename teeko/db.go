@@ -0,0 +1,602 @@
+package teeko
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Database I/O
+const dbMagic = "TEEK"
+const dbVersionV1 = uint32(1)
+const dbVersionV2 = uint32(2)
+const dbVersionV3 = uint32(3)
+
+// Symmetry reduction constants
+const BLOCK_SIZE = 1024
+
+// ScoreTable looks up scores in either database format.
+// Scores are from the perspective of A, the player to move.
+type ScoreTable interface {
+	Lookup(a, b uint32, n int) int8
+}
+
+// DB holds scores for canonical positions only (v2 and v3 formats)
+type DB struct {
+	Checkpoints [9][]int  // Checkpoints[n][i] = rank at i*BLOCK_SIZE
+	Scores      [9][]int8 // dense array of canonical scores only
+	Counts      [9]int    // count of canonical positions per piece count
+
+	// Optional constant-time rank index (persisted in v3)
+	canon [9][]uint64 // bit g set if Goedel index g is canonical
+	ranks [9][]uint32 // ranks[n][w] = canonical positions before word w
+}
+
+// Rank counts canonical positions with Goedel index < canonG,
+// in constant time when the index is available
+func (db *DB) Rank(n, canonG int) int {
+	if words := db.canon[n]; words != nil {
+		w := canonG >> 6
+		return int(db.ranks[n][w]) + bits.OnesCount64(words[w]&(1<<(canonG&63)-1))
+	}
+	return db.RankScan(n, canonG)
+}
+
+// RankScan counts canonical positions with Goedel index < canonG
+// from the checkpoints alone, like rankQuery in bot.ts
+func (db *DB) RankScan(n, canonG int) int {
+	blockIdx := canonG / BLOCK_SIZE
+	rank := db.Checkpoints[n][blockIdx]
+
+	// Scan from block start to canonG, counting canonicals
+	for g := blockIdx * BLOCK_SIZE; g < canonG; g++ {
+		if IsCanonical(g, n) {
+			rank++
+		}
+	}
+	return rank
+}
+
+// Unrank returns the Goedel index of the canonical position with the given rank
+func (db *DB) Unrank(n, rank int) int {
+	if words := db.canon[n]; words != nil {
+		// Last word starting at or below rank, then select within it
+		ranks := db.ranks[n]
+		lo, hi := 0, len(ranks)-1
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if int(ranks[mid]) <= rank {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		word := words[lo]
+		for range rank - int(ranks[lo]) {
+			word &= word - 1
+		}
+		return lo<<6 + bits.TrailingZeros64(word)
+	}
+
+	cps := db.Checkpoints[n]
+	// Last block starting at or below rank
+	lo, hi := 0, len(cps)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if cps[mid] <= rank {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	r := cps[lo]
+	for g := lo * BLOCK_SIZE; ; g++ {
+		if IsCanonical(g, n) {
+			if r == rank {
+				return g
+			}
+			r++
+		}
+	}
+}
+
+// LongestWins finds the longest forced win for each side across all tables.
+// Either result is nil when that side has no forced win.
+func (db *DB) LongestWins() (aWin, bWin *Win) {
+	return longestWins(&db.Scores, db.Unrank)
+}
+
+// Lookup score for a position using canonical form. Corrupt v2 checkpoints can
+// rank a position past the scores; it then scores ScoreNone.
+func (db *DB) Lookup(a, b uint32, n int) int8 {
+	canonG := Canonical(a, b, n)
+	rank := db.Rank(n, canonG)
+	if rank >= len(db.Scores[n]) {
+		return ScoreNone
+	}
+	return db.Scores[n][rank]
+}
+
+// Store score for a position using canonical form
+func (db *DB) Store(a, b uint32, n int, score int8) {
+	canonG := Canonical(a, b, n)
+	db.Scores[n][db.Rank(n, canonG)] = score
+}
+
+// Score returns the score of a position from the perspective of A, the player to move
+func (db *DB) Score(a, b uint32) int8 {
+	return db.Lookup(a, b, bits.OnesCount32(a)+bits.OnesCount32(b))
+}
+
+// Moves lists every legal action for the player to move with its score
+// from their perspective, like generateMoves in bot.ts
+func (db *DB) Moves(board Board) []Move {
+	return GenerateMoves(db, board)
+}
+
+// GenerateMoves lists the actions of the player to move with their scores from
+// that player's perspective, looked up in any ScoreTable, like generateMoves in
+// bot.ts. Every action listed is legal, so Board.Apply accepts it.
+func GenerateMoves(t ScoreTable, board Board) []Move {
+	mover, other := board.Mover()
+	n := bits.OnesCount32(mover) + bits.OnesCount32(other)
+	ab := mover | other
+	var moves []Move
+
+	if n == 8 {
+		for sq := range Size {
+			if mover&(1<<sq) == 0 {
+				continue
+			}
+			newMover := mover ^ (1 << sq)
+			for dest := range Size {
+				if Neighs[sq]&^ab&(1<<dest) == 0 {
+					continue
+				}
+				moves = append(moves, Move{Action{sq, dest}, moveScore(t.Lookup(other, newMover|(1<<dest), 8))})
+			}
+		}
+	} else {
+		for sq := range Size {
+			if ab&(1<<sq) != 0 {
+				continue
+			}
+			moves = append(moves, Move{Drop(sq), moveScore(t.Lookup(other, mover|(1<<sq), n+1))})
+		}
+	}
+	return moves
+}
+
+// Score of a move from the score of the position it leads to, for the opponent
+func moveScore(s int8) int8 {
+	if s == ScoreNone {
+		return ScoreNone
+	}
+	return -s
+}
+
+// Build checkpoints and the rank index for piece count n
+func (db *DB) buildCheckpoints(n int) {
+	numBlocks := (Configs[n] + BLOCK_SIZE - 1) / BLOCK_SIZE
+	db.Checkpoints[n] = make([]int, numBlocks+1)
+	db.canon[n] = make([]uint64, (Configs[n]+63)/64)
+
+	rank := 0
+	for g := 0; g < Configs[n]; g++ {
+		if g%BLOCK_SIZE == 0 {
+			db.Checkpoints[n][g/BLOCK_SIZE] = rank
+		}
+		if IsCanonical(g, n) {
+			db.canon[n][g>>6] |= 1 << (g & 63)
+			rank++
+		}
+	}
+	db.Checkpoints[n][numBlocks] = rank
+	db.Counts[n] = rank
+	db.Scores[n] = make([]int8, rank)
+	db.buildRanks(n)
+}
+
+// Build the rank directory from the canonical flags
+func (db *DB) buildRanks(n int) {
+	db.ranks[n] = make([]uint32, len(db.canon[n]))
+	rank := 0
+	for w, word := range db.canon[n] {
+		db.ranks[n][w] = uint32(rank)
+		rank += bits.OnesCount64(word)
+	}
+}
+
+// Derive checkpoints from the rank index
+func (db *DB) checkpointsFromRanks(n int) {
+	numBlocks := (Configs[n] + BLOCK_SIZE - 1) / BLOCK_SIZE
+	db.Checkpoints[n] = make([]int, numBlocks+1)
+	for i := range numBlocks {
+		db.Checkpoints[n][i] = int(db.ranks[n][i*BLOCK_SIZE/64])
+	}
+	db.Checkpoints[n][numBlocks] = db.Counts[n]
+}
+
+// Indexed reports whether lookups use the constant-time rank index
+func (db *DB) Indexed() bool {
+	return db.canon[0] != nil
+}
+
+// BuildIndex computes the constant-time rank index for a database loaded from a v2 file,
+// or for an empty database whose counts it then fills in
+func (db *DB) BuildIndex() {
+	if db.Indexed() {
+		return
+	}
+	numWorkers := runtime.NumCPU()
+	for n := range 9 {
+		words := make([]uint64, (Configs[n]+63)/64)
+		chunkSize := (len(words) + numWorkers - 1) / numWorkers
+		var wg sync.WaitGroup
+		for w := 0; w < numWorkers; w++ {
+			wg.Add(1)
+			startIdx := w * chunkSize
+			endIdx := min(startIdx+chunkSize, len(words))
+			go func(startIdx, endIdx int) {
+				defer wg.Done()
+				for i := startIdx; i < endIdx; i++ {
+					for g := i * 64; g < min(i*64+64, Configs[n]); g++ {
+						if IsCanonical(g, n) {
+							words[i] |= 1 << (g & 63)
+						}
+					}
+				}
+			}(startIdx, endIdx)
+		}
+		wg.Wait()
+		db.canon[n] = words
+		db.buildRanks(n)
+		db.Counts[n] = int(db.ranks[n][len(words)-1]) + bits.OnesCount64(words[len(words)-1])
+	}
+}
+
+// CheckIndex recomputes canonical counts and checkpoints for every piece count
+// and compares them to the stored ones
+func (db *DB) CheckIndex() error {
+	for n := range 9 {
+		var fresh DB
+		fresh.buildCheckpoints(n)
+		if fresh.Counts[n] != db.Counts[n] {
+			return fmt.Errorf("%d pieces: %d canonical positions, want %d", n, db.Counts[n], fresh.Counts[n])
+		}
+		for i, cp := range fresh.Checkpoints[n] {
+			if db.Checkpoints[n][i] != cp {
+				return fmt.Errorf("%d pieces: checkpoint %d is %d, want %d", n, i, db.Checkpoints[n][i], cp)
+			}
+		}
+		if db.canon[n] != nil {
+			for i, word := range fresh.canon[n] {
+				if db.canon[n][i] != word {
+					return fmt.Errorf("%d pieces: canonical flags for %d..%d differ", n, i*64, i*64+63)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Compress full score tables into canonical format
+func compressTables(scores *[9][]int8) *DB {
+	db := &DB{}
+	for n := range 9 {
+		db.buildCheckpoints(n)
+
+		// Copy scores from full table to canonical table
+		rank := 0
+		for g := 0; g < Configs[n]; g++ {
+			if db.canon[n][g>>6]&(1<<(g&63)) != 0 {
+				db.Scores[n][rank] = scores[n][g]
+				rank++
+			}
+		}
+	}
+	return db
+}
+
+// Compress full score tables into canonical format.
+// The solver's full tables are released afterwards to free memory.
+// Canonical solvers hand over their tables as they are.
+func (s *Solver) Compress() *DB {
+	if s.canon != nil {
+		db := s.canonicalDB()
+		s.Scores = [9][]int8{}
+		return db
+	}
+	s.logf("Compressing to canonical format…\n")
+	start := time.Now()
+	db := compressTables(&s.Scores)
+
+	var totalFull, totalCanonical int
+	for n := range 9 {
+		totalFull += Configs[n]
+		totalCanonical += db.Counts[n]
+	}
+
+	// Clear the full score tables to free memory
+	for n := range 9 {
+		s.Scores[n] = nil
+	}
+
+	s.logf("  Compressed %d -> %d positions (%.1fx)\n",
+		totalFull, totalCanonical, float64(totalFull)/float64(totalCanonical))
+	s.logf("  Compression completed in %v\n", time.Since(start).Round(time.Millisecond))
+	return db
+}
+
+// Database sharing a canonical solver's tables and index
+func (s *Solver) canonicalDB() *DB {
+	db := *s.canon
+	db.Scores = s.Scores
+	return &db
+}
+
+// Expand canonical scores back into full tables (v1 format).
+// Goedel indices are visited in order, so the canonical form of a
+// non-canonical position (always smaller) has already been filled in.
+func (db *DB) Expand() *FullDB {
+	full := &FullDB{}
+	for n := range 9 {
+		table := make([]int8, Configs[n])
+		rank := 0
+		for g := range table {
+			a, b := Degoedel(g, n)
+			if c := Canonical(a, b, n); c == g {
+				table[g] = db.Scores[n][rank]
+				rank++
+			} else {
+				table[g] = table[c]
+			}
+		}
+		full.Scores[n] = table
+	}
+	return full
+}
+
+// Write the database in v2 format
+func (db *DB) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(dbMagic)
+	binary.Write(bw, binary.LittleEndian, dbVersionV2)
+
+	for n := range 9 {
+		// Write canonical count
+		binary.Write(bw, binary.LittleEndian, uint32(db.Counts[n]))
+		// Write number of checkpoints
+		binary.Write(bw, binary.LittleEndian, uint32(len(db.Checkpoints[n])))
+		// Write checkpoints
+		for _, cp := range db.Checkpoints[n] {
+			binary.Write(bw, binary.LittleEndian, uint32(cp))
+		}
+		db.writeScores(bw, n)
+	}
+	// bufio.Writer keeps the first error, so checking Flush is enough
+	return bw.Flush()
+}
+
+// Write the database in v3 format, replacing checkpoints with canonical flags.
+// Clients rebuild the rank directory with one popcount per word.
+func (db *DB) WriteV3(w io.Writer) error {
+	db.BuildIndex()
+	bw := bufio.NewWriter(w)
+	bw.WriteString(dbMagic)
+	binary.Write(bw, binary.LittleEndian, dbVersionV3)
+
+	for n := range 9 {
+		// Write canonical count
+		binary.Write(bw, binary.LittleEndian, uint32(db.Counts[n]))
+		// Write number of 64-bit words of canonical flags
+		binary.Write(bw, binary.LittleEndian, uint32(len(db.canon[n])))
+		binary.Write(bw, binary.LittleEndian, db.canon[n])
+		db.writeScores(bw, n)
+	}
+	return bw.Flush()
+}
+
+func (db *DB) writeScores(bw *bufio.Writer, n int) {
+	buf := make([]byte, len(db.Scores[n]))
+	for i, s := range db.Scores[n] {
+		buf[i] = byte(s)
+	}
+	bw.Write(buf)
+}
+
+// Save the database to filename in v2 format
+func (db *DB) Save(filename string) error {
+	return saveWith(filename, db.Write)
+}
+
+// SaveV3 saves the database to filename in v3 format
+func (db *DB) SaveV3(filename string) error {
+	return saveWith(filename, db.WriteV3)
+}
+
+func saveWith(filename string, write func(io.Writer) error) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var errBadMagic = errors.New("not a teeko database")
+
+// Read magic and version
+func readHeader(br *bufio.Reader) (uint32, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return 0, fmt.Errorf("reading header: %w", err)
+	}
+	if string(header[:4]) != dbMagic {
+		return 0, errBadMagic
+	}
+	return binary.LittleEndian.Uint32(header[4:]), nil
+}
+
+func expectEOF(br *bufio.Reader) error {
+	if _, err := br.ReadByte(); err != io.EOF {
+		return errors.New("trailing data after database")
+	}
+	return nil
+}
+
+// LoadDB reads a v2 or v3 database, validating its header and section sizes
+func LoadDB(r io.Reader) (*DB, error) {
+	br := bufio.NewReader(r)
+	version, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	if version != dbVersionV2 && version != dbVersionV3 {
+		return nil, fmt.Errorf("unsupported database version %d", version)
+	}
+
+	db := &DB{}
+	for n := range 9 {
+		var sizes [2]uint32
+		if err := binary.Read(br, binary.LittleEndian, &sizes); err != nil {
+			return nil, fmt.Errorf("reading %d-piece section: %w", n, err)
+		}
+		canonCount := int(sizes[0])
+		if canonCount > Configs[n] {
+			return nil, fmt.Errorf("%d-piece section has %d canonical positions, more than %d", n, canonCount, Configs[n])
+		}
+		db.Counts[n] = canonCount
+
+		if version == dbVersionV2 {
+			err = db.readCheckpoints(br, n, int(sizes[1]))
+		} else {
+			err = db.readCanonicalFlags(br, n, int(sizes[1]))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, canonCount)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, fmt.Errorf("reading %d-piece scores: %w", n, err)
+		}
+		db.Scores[n] = make([]int8, canonCount)
+		for i, s := range buf {
+			db.Scores[n][i] = int8(s)
+		}
+	}
+
+	if err := expectEOF(br); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *DB) readCheckpoints(br *bufio.Reader, n, numCheckpoints int) error {
+	if want := (Configs[n]+BLOCK_SIZE-1)/BLOCK_SIZE + 1; numCheckpoints != want {
+		return fmt.Errorf("%d-piece section has %d checkpoints, want %d", n, numCheckpoints, want)
+	}
+	raw := make([]uint32, numCheckpoints)
+	if err := binary.Read(br, binary.LittleEndian, raw); err != nil {
+		return fmt.Errorf("reading %d-piece checkpoints: %w", n, err)
+	}
+	db.Checkpoints[n] = make([]int, numCheckpoints)
+	for i, cp := range raw {
+		if i > 0 && int(cp) < db.Checkpoints[n][i-1] {
+			return fmt.Errorf("%d-piece checkpoint %d decreases", n, i)
+		}
+		db.Checkpoints[n][i] = int(cp)
+	}
+	if first := db.Checkpoints[n][0]; first != 0 {
+		return fmt.Errorf("%d-piece checkpoints start at %d, want 0", n, first)
+	}
+	if last := db.Checkpoints[n][numCheckpoints-1]; last != db.Counts[n] {
+		return fmt.Errorf("%d-piece checkpoints end at %d, want %d", n, last, db.Counts[n])
+	}
+	// Ranks in the last block are counted from its checkpoint, and must stay within the scores
+	lastBlock := numCheckpoints - 2
+	rank := db.Checkpoints[n][lastBlock]
+	for g := lastBlock * BLOCK_SIZE; g < Configs[n]; g++ {
+		if IsCanonical(g, n) {
+			rank++
+		}
+	}
+	if rank != db.Counts[n] {
+		return fmt.Errorf("%d-piece last checkpoint leads to %d canonical positions, want %d", n, rank, db.Counts[n])
+	}
+	return nil
+}
+
+func (db *DB) readCanonicalFlags(br *bufio.Reader, n, numWords int) error {
+	if want := (Configs[n] + 63) / 64; numWords != want {
+		return fmt.Errorf("%d-piece section has %d flag words, want %d", n, numWords, want)
+	}
+	words := make([]uint64, numWords)
+	if err := binary.Read(br, binary.LittleEndian, words); err != nil {
+		return fmt.Errorf("reading %d-piece canonical flags: %w", n, err)
+	}
+	if tail := Configs[n] % 64; tail != 0 && words[numWords-1]>>tail != 0 {
+		return fmt.Errorf("%d-piece canonical flags set past the last position", n)
+	}
+	db.canon[n] = words
+	db.buildRanks(n)
+	if total := int(db.ranks[n][numWords-1]) + bits.OnesCount64(words[numWords-1]); total != db.Counts[n] {
+		return fmt.Errorf("%d-piece section flags %d canonical positions, want %d", n, total, db.Counts[n])
+	}
+	db.checkpointsFromRanks(n)
+	return nil
+}
+
+// OpenDB loads a v2 or v3 database from path
+func OpenDB(path string) (*DB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	db, err := LoadDB(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Open loads a database of any version from path,
+// returning a *DB for v2 and v3 files and a *FullDB for v1 files
+func Open(path string) (ScoreTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	header, err := br.Peek(8)
+	if err != nil || string(header[:4]) != dbMagic {
+		return nil, fmt.Errorf("%s: %w", path, errBadMagic)
+	}
+
+	var t ScoreTable
+	switch version := binary.LittleEndian.Uint32(header[4:]); version {
+	case dbVersionV1:
+		t, err = LoadFullDB(br)
+	case dbVersionV2, dbVersionV3:
+		t, err = LoadDB(br)
+	default:
+		err = fmt.Errorf("unsupported database version %d", version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,139 @@
+package teeko
+
+import (
+	"bytes"
+	"math/bits"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// A database small enough to build in tests: every table only holds the
+// canonical positions of its last checkpoint block, as if the others had none
+func lastBlockDB() *DB {
+	db := &DB{}
+	for n := range 9 {
+		numBlocks := (Configs[n] + BLOCK_SIZE - 1) / BLOCK_SIZE
+		count := 0
+		for g := (numBlocks - 1) * BLOCK_SIZE; g < Configs[n]; g++ {
+			if IsCanonical(g, n) {
+				count++
+			}
+		}
+		db.Checkpoints[n] = make([]int, numBlocks+1)
+		db.Checkpoints[n][numBlocks] = count
+		db.Counts[n] = count
+		db.Scores[n] = make([]int8, count)
+	}
+	return db
+}
+
+func TestLoadDBCheckpoints(t *testing.T) {
+	load := func(db *DB) error {
+		var buf bytes.Buffer
+		if err := db.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadDB(&buf)
+		return err
+	}
+	if err := load(lastBlockDB()); err != nil {
+		t.Fatalf("valid database: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		corrupt func(db *DB)
+		want    string
+	}{
+		{"first checkpoint", func(db *DB) {
+			for i := range db.Checkpoints[5] {
+				db.Checkpoints[5][i]++
+			}
+			db.Counts[5]++
+			db.Scores[5] = append(db.Scores[5], 0)
+		}, "start at 1"},
+		{"count beyond the last block", func(db *DB) {
+			db.Checkpoints[6][len(db.Checkpoints[6])-1]++
+			db.Counts[6]++
+			db.Scores[6] = append(db.Scores[6], 0)
+		}, "last checkpoint leads to"},
+		{"decreasing", func(db *DB) {
+			db.Checkpoints[7][1] = 1
+		}, "decreases"},
+	} {
+		db := lastBlockDB()
+		tc.corrupt(db)
+		if err := load(db); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error %v, want %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestLookupPastScores(t *testing.T) {
+	var buf bytes.Buffer
+	if err := lastBlockDB().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// The checkpoints claim the blocks before the last one hold no canonical
+	// position, which loading does not check: theirs rank past the scores
+	db, err := LoadDB(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 7
+	for g := range Configs[n] {
+		if !IsCanonical(g, n) {
+			continue
+		}
+		a, b := Degoedel(g, n)
+		if s := db.Lookup(a, b, n); s != ScoreNone {
+			t.Errorf("score %d, want ScoreNone", s)
+		}
+		// A drop leading there is unknown too, not a win
+		sq := bits.TrailingZeros32(b)
+		for _, m := range GenerateMoves(db, Board{A: b &^ (1 << sq), B: a, M: make([]Action, 6)}) {
+			if m.Action == Drop(sq) && m.Score != ScoreNone {
+				t.Errorf("drop on %d scores %d, want ScoreNone", sq, m.Score)
+			}
+		}
+		return
+	}
+}
+
+// Piece count of the benchmarked table. Blocks hold BLOCK_SIZE positions whatever
+// the piece count, so scans cost as much as in the 8-piece table, which takes
+// much longer to index.
+const benchPieces = 6
+
+var benchDB = sync.OnceValues(func() (v2, v3 *DB) {
+	v3 = &DB{}
+	v3.buildCheckpoints(benchPieces)
+	// The same tables without the rank index, as loaded from a v2 file
+	v2 = &DB{Checkpoints: v3.Checkpoints, Scores: v3.Scores, Counts: v3.Counts}
+	return v2, v3
+})
+
+func benchmarkLookup(b *testing.B, db *DB) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	positions := make([]Position, 4096)
+	for i := range positions {
+		positions[i].A, positions[i].B = Degoedel(rng.IntN(Configs[benchPieces]), benchPieces)
+	}
+	b.ResetTimer()
+	for i := range b.N {
+		p := positions[i%len(positions)]
+		db.Lookup(p.A, p.B, benchPieces)
+	}
+}
+
+func BenchmarkLookupV2(b *testing.B) {
+	v2, _ := benchDB()
+	benchmarkLookup(b, v2)
+}
+
+func BenchmarkLookupV3(b *testing.B) {
+	_, v3 := benchDB()
+	benchmarkLookup(b, v3)
+}
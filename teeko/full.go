@@ -0,0 +1,122 @@
+package teeko
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+)
+
+// FullDB holds a score for every Goedel index of every piece count (v1 format)
+type FullDB struct {
+	Scores [9][]int8
+}
+
+// Tables returns the solver's full score tables as a FullDB sharing its memory,
+// or expanded from a canonical solver's tables
+func (s *Solver) Tables() *FullDB {
+	if s.canon != nil {
+		return s.canonicalDB().Expand()
+	}
+	return &FullDB{s.Scores}
+}
+
+// Lookup score for a position by its Goedel index
+func (f *FullDB) Lookup(a, b uint32, n int) int8 {
+	return f.Scores[n][Goedel(a, b, n)]
+}
+
+// Score returns the score of a position from the perspective of A, the player to move
+func (f *FullDB) Score(a, b uint32) int8 {
+	return f.Lookup(a, b, bits.OnesCount32(a)+bits.OnesCount32(b))
+}
+
+// Moves lists every legal action for the player to move with its score
+func (f *FullDB) Moves(board Board) []Move {
+	return GenerateMoves(f, board)
+}
+
+// Compress into canonical format
+func (f *FullDB) Compress() *DB {
+	return compressTables(&f.Scores)
+}
+
+// Write the tables in v1 format: for each piece count, its size then one byte per Goedel index
+func (f *FullDB) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(dbMagic)
+	binary.Write(bw, binary.LittleEndian, dbVersionV1)
+
+	for n := range 9 {
+		binary.Write(bw, binary.LittleEndian, uint32(len(f.Scores[n])))
+		buf := make([]byte, len(f.Scores[n]))
+		for i, s := range f.Scores[n] {
+			buf[i] = byte(s)
+		}
+		bw.Write(buf)
+	}
+	return bw.Flush()
+}
+
+// Save the tables to filename in v1 format
+func (f *FullDB) Save(filename string) error {
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := f.Write(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// LoadFullDB reads a v1 database, validating its header and table sizes
+func LoadFullDB(r io.Reader) (*FullDB, error) {
+	br := bufio.NewReader(r)
+	if version, err := readHeader(br); err != nil {
+		return nil, err
+	} else if version != dbVersionV1 {
+		return nil, fmt.Errorf("unsupported database version %d, want %d", version, dbVersionV1)
+	}
+
+	f := &FullDB{}
+	for n := range 9 {
+		var size uint32
+		if err := binary.Read(br, binary.LittleEndian, &size); err != nil {
+			return nil, fmt.Errorf("reading %d-piece section: %w", n, err)
+		}
+		if int(size) != Configs[n] {
+			return nil, fmt.Errorf("size mismatch for %d pieces: %d, want %d", n, size, Configs[n])
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, fmt.Errorf("reading %d-piece scores: %w", n, err)
+		}
+		f.Scores[n] = make([]int8, size)
+		for i, s := range buf {
+			f.Scores[n][i] = int8(s)
+		}
+	}
+
+	if err := expectEOF(br); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// OpenFullDB loads a v1 database from path
+func OpenFullDB(path string) (*FullDB, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	f, err := LoadFullDB(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,101 @@
+package teeko
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Action is a drop (From < 0) or a move of a piece from From to To
+type Action struct {
+	From, To int
+}
+
+// Drop returns the action of dropping a piece on sq
+func Drop(sq int) Action {
+	return Action{-1, sq}
+}
+
+// UnknownAction stands for an action that was not recorded. Boards set up from a
+// position have one as their history when B is to move, so that the length of
+// the history still gives the player to move.
+var UnknownAction = Action{-1, -1}
+
+// IsDrop reports whether the action is a drop
+func (a Action) IsDrop() bool {
+	return a.From < 0
+}
+
+// Actions are encoded as in Board.m from model.ts: a number for drops, [from, to] for moves
+func (a Action) MarshalJSON() ([]byte, error) {
+	if a.IsDrop() {
+		return json.Marshal(a.To)
+	}
+	return json.Marshal([2]int{a.From, a.To})
+}
+
+func (a *Action) UnmarshalJSON(data []byte) error {
+	var sq int
+	if err := json.Unmarshal(data, &sq); err == nil {
+		*a = Drop(sq)
+		return nil
+	}
+	var move [2]int
+	if err := json.Unmarshal(data, &move); err != nil {
+		return fmt.Errorf("action must be a square or a [from, to] pair: %s", data)
+	}
+	if move[0] < 0 {
+		// Would otherwise read as a drop
+		return fmt.Errorf("%w: move from %d", ErrOffBoard, move[0])
+	}
+	*a = Action{move[0], move[1]}
+	return nil
+}
+
+// Board mirrors Board from model.ts
+type Board struct {
+	A uint32   `json:"a"` // Where blue has pieces (bitset)
+	B uint32   `json:"b"` // Where red has pieces (bitset)
+	M []Action `json:"m"` // Actions, either drop or move
+	P bool     `json:"p"` // Playing or not
+}
+
+// Mover returns the pieces of the player to move and of their opponent
+func (b Board) Mover() (mover, other uint32) {
+	if len(b.M)%2 == 0 {
+		return b.A, b.B
+	}
+	return b.B, b.A
+}
+
+// Move is an action with its score from the mover's perspective
+type Move struct {
+	Action
+	Score int8
+}
+
+type jsonMove struct {
+	From  *int `json:"from,omitempty"`
+	To    int  `json:"to"`
+	Score int8 `json:"score"`
+}
+
+// Moves are encoded as in bot.ts: {from?, to, score}
+func (m Move) MarshalJSON() ([]byte, error) {
+	jm := jsonMove{To: m.To, Score: m.Score}
+	if !m.IsDrop() {
+		jm.From = &m.From
+	}
+	return json.Marshal(jm)
+}
+
+func (m *Move) UnmarshalJSON(data []byte) error {
+	var jm jsonMove
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return err
+	}
+	*m = Move{Drop(jm.To), jm.Score}
+	if jm.From != nil {
+		m.From = *jm.From
+	}
+	return nil
+}
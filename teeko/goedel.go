@@ -0,0 +1,94 @@
+package teeko
+
+import "math/bits"
+
+// Goedel numbering - bijection between positions and integers
+func Goedel(a, b uint32, n int) int {
+	if n == 0 {
+		return 0
+	}
+	ab := a | b
+	posNum, patNum := 0, 0
+	pat := uint32(0)
+	patBit := uint32(1 << (n - 1))
+	nRed := (n + 1) / 2
+
+	for j := range Size {
+		remaining := bits.OnesCount32(ab >> j)
+		if ab&(1<<j) != 0 {
+			remaining--
+			if b&(1<<j) != 0 {
+				pat |= patBit
+			}
+			patBit >>= 1
+			posNum += choose[Size-j-1][remaining+1]
+		}
+	}
+
+	for j := range n {
+		if pat&(1<<j) != 0 {
+			nRed--
+			patNum += choose[n-j-1][nRed+1]
+		}
+	}
+
+	return posNum + Positions[n]*patNum
+}
+
+// Degoedel is the inverse of Goedel
+func Degoedel(idx, n int) (a, b uint32) {
+	if n == 0 {
+		return 0, 0
+	}
+	patNum := idx / Positions[n]
+	posNum := idx % Positions[n]
+
+	// Decode pattern
+	patWalk := Patterns[n]
+	pat := uint32(0)
+	nRed := (n + 1) / 2
+	for j := range n {
+		pcs := n - j
+		temp := (patWalk * (pcs - nRed)) / pcs
+		if patNum >= temp {
+			patNum -= temp
+			patWalk = (patWalk * nRed) / pcs
+			nRed--
+			pat |= 1 << j
+		} else {
+			patWalk = temp
+		}
+	}
+
+	// Decode position
+	posWalk := Positions[n]
+	pcs := n
+	patBit := uint32(1 << (n - 1))
+	for j := range Size {
+		locs := Size - j
+		temp := (posWalk * (locs - pcs)) / locs
+		if posNum >= temp {
+			posNum -= temp
+			posWalk = (posWalk * pcs) / locs
+			pcs--
+			if pat&patBit != 0 {
+				b |= 1 << j
+			} else {
+				a |= 1 << j
+			}
+			patBit >>= 1
+		} else {
+			posWalk = temp
+		}
+	}
+	return
+}
+
+// Pack/unpack position pairs
+func packPos(a, b uint32) uint64 {
+	return (uint64(a) << 32) | uint64(b)
+}
+
+func unpackPos(packed uint64) (a, b uint32) {
+	return uint32(packed >> 32), uint32(packed)
+}
@@ -0,0 +1,121 @@
+package teeko
+
+import "math/bits"
+
+// Square priority for move ordering (center = best)
+// Stored as bitmasks for each priority level (4=best, 0=worst)
+var priorityMasks = [5]uint32{
+	0b1000100000000000000010001, // priority 0: corners
+	0b0111010001100011000101110, // priority 1: edges
+	0b0000001010000000101000000, // priority 2: inner ring corners
+	0b0000000100010100010000000, // priority 3: inner ring edges
+	0b0000000000001000000000000, // priority 4: center
+}
+
+// Negamax with alpha-beta pruning for heuristic evaluation
+// mover = pieces of player to move, other = opponent pieces
+// Returns score from mover's perspective
+func Negamax(mover, other uint32, depth, alpha, beta int) int {
+	if depth == 0 {
+		return evalPosition(mover, other) - evalPosition(other, mover)
+	}
+
+	occupied := mover | other
+	// Try moves in priority order (best destinations first)
+	for pri := 4; pri >= 0; pri-- {
+		destMask := priorityMasks[pri] &^ occupied
+		for p := mover; p != 0; {
+			piece := p & -p
+			p ^= piece
+			pos := bits.TrailingZeros32(piece)
+			newMover := mover ^ piece
+			for dests := Neighs[pos] & destMask; dests != 0; {
+				dest := dests & -dests
+				dests ^= dest
+				score := -Negamax(other, newMover|dest, depth-1, -beta, -alpha)
+				if score > alpha {
+					alpha = score
+				}
+				if alpha >= beta {
+					return alpha
+				}
+			}
+		}
+	}
+	return alpha
+}
+
+// Heuristic evaluation for drawn positions with 4-ply alpha-beta search
+// Returns a score from -80 to +80 based on positional advantage
+func Heuristic(a, b uint32) int8 {
+	score := Negamax(a, b, 4, -1000, 1000)
+	if score > int(ScoreHeuristicMax) {
+		return ScoreHeuristicMax
+	}
+	if score < -int(ScoreHeuristicMax) {
+		return -ScoreHeuristicMax
+	}
+	return int8(score)
+}
+
+// Precomputed: for each square, which patterns include it
+var squarePatterns [Size][]uint32
+
+// Precomputed: central squares bitmask
+const centralSquares = uint32(0b0000001110011100111000000)
+
+func init() {
+	for sq := 0; sq < Size; sq++ {
+		bit := uint32(1) << sq
+		for _, pattern := range WinPatterns {
+			if pattern&bit != 0 {
+				squarePatterns[sq] = append(squarePatterns[sq], pattern)
+			}
+		}
+	}
+}
+
+// Evaluate how good a position is for the player with pieces 'mine'
+func evalPosition(mine, theirs uint32) int {
+	score := 0
+	occupied := mine | theirs
+
+	// Track which patterns we've already scored
+	var scored uint64
+
+	// Only check patterns that include at least one of my pieces
+	for m := mine; m != 0; {
+		sq := bits.TrailingZeros32(m)
+		m &= m - 1
+		for i, pattern := range squarePatterns[sq] {
+			idx := uint64(sq*16 + i) // Unique index for this (square, pattern) pair
+			if scored&(1<<idx) != 0 {
+				continue
+			}
+			scored |= 1 << idx
+
+			// Skip patterns blocked by opponent
+			if pattern&theirs != 0 {
+				continue
+			}
+			myPieces := bits.OnesCount32(pattern & mine)
+			switch myPieces {
+			case 4:
+				score += 100
+			case 3:
+				if bits.OnesCount32(pattern&^occupied) == 1 {
+					score += 20
+				} else {
+					score += 8
+				}
+			case 2:
+				score += 2
+			case 1:
+				score += 1
+			}
+		}
+	}
+
+	score += bits.OnesCount32(mine&centralSquares) * 2
+	return score
+}
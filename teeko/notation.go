@@ -0,0 +1,109 @@
+package teeko
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Notation names columns a–e from left to right and rows 1–5 from bottom to top,
+// so square 0 (top left) is a5 and square 24 (bottom right) is e1.
+// Drops are written as their square (c3) and moves as two squares (c3-d4).
+
+// ErrNotation reports text that is not a square, drop or move
+var ErrNotation = errors.New("expected a square like c3 or a move like c3-d4")
+
+// SquareName returns the notation of a square
+func SquareName(sq int) string {
+	return string([]byte{'a' + byte(sq%Edge), '1' + byte(Edge-1-sq/Edge)})
+}
+
+// ParseSquare reads a square such as c3, in either case
+func ParseSquare(s string) (int, error) {
+	if len(s) != 2 || !isLetter(s[0]) || s[1] < '0' || s[1] > '9' {
+		return 0, fmt.Errorf("%q: %w", s, ErrNotation)
+	}
+	col := int(s[0]|0x20) - 'a'
+	row := Edge - 1 - (int(s[1]) - '1')
+	if col < 0 || col >= Edge || row < 0 || row >= Edge {
+		return 0, fmt.Errorf("%q: %w", s, ErrOffBoard)
+	}
+	return row*Edge + col, nil
+}
+
+func isLetter(c byte) bool {
+	c |= 0x20
+	return c >= 'a' && c <= 'z'
+}
+
+// SquareNames lists the squares of a mask in index order
+func SquareNames(mask uint32) []string {
+	var names []string
+	for sq := range Size {
+		if mask&(1<<sq) != 0 {
+			names = append(names, SquareName(sq))
+		}
+	}
+	return names
+}
+
+// String returns the notation of an action
+func (a Action) String() string {
+	if a == UnknownAction {
+		return "?"
+	}
+	if a.IsDrop() {
+		return SquareName(a.To)
+	}
+	return SquareName(a.From) + "-" + SquareName(a.To)
+}
+
+// ParseAction reads a drop (c3) or a move between neighbouring squares (c3-d4)
+func ParseAction(s string) (Action, error) {
+	from, to, isMove := strings.Cut(s, "-")
+	if !isMove {
+		sq, err := ParseSquare(s)
+		if err != nil {
+			return Action{}, err
+		}
+		return Drop(sq), nil
+	}
+	f, err := ParseSquare(from)
+	if err != nil {
+		return Action{}, err
+	}
+	t, err := ParseSquare(to)
+	if err != nil {
+		return Action{}, err
+	}
+	if Neighs[f]&(1<<t) == 0 {
+		return Action{}, fmt.Errorf("%q: %w", s, ErrNotAdjacent)
+	}
+	return Action{f, t}, nil
+}
+
+// FormatActions writes a history as space-separated actions
+func FormatActions(history []Action) string {
+	names := make([]string, len(history))
+	for i, a := range history {
+		names[i] = a.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// ParseActions reads a history of actions separated by spaces or commas,
+// giving the Board.M of the game
+func ParseActions(s string) ([]Action, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	history := make([]Action, 0, len(fields))
+	for i, f := range fields {
+		a, err := ParseAction(f)
+		if err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+		history = append(history, a)
+	}
+	return history, nil
+}
@@ -0,0 +1,197 @@
+package teeko
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// A position string lists the rows from 5 down to 1, left to right, with A and B
+// for the players' pieces and . for empty squares, then the player to move and
+// the phase, like FEN in chess:
+//
+//	AAA../BB.../...../...../..... b drop
+//
+// The phase is drop until both players have four pieces, then move.
+// ParsePosition also reads the grid printed by the CLI, see parseGrid.
+
+// ErrPosition reports a position string that cannot be read, or an impossible position
+var ErrPosition = errors.New("invalid position")
+
+// Phases of a position string
+const (
+	PhaseDrop = "drop"
+	PhaseMove = "move"
+)
+
+// FormatPosition writes the position string of a board
+func FormatPosition(b Board) string {
+	var sb strings.Builder
+	for sq := range Size {
+		if sq > 0 && sq%Edge == 0 {
+			sb.WriteByte('/')
+		}
+		switch {
+		case b.A&(1<<sq) != 0:
+			sb.WriteByte('A')
+		case b.B&(1<<sq) != 0:
+			sb.WriteByte('B')
+		default:
+			sb.WriteByte('.')
+		}
+	}
+	turn := "a"
+	if len(b.M)%2 == 1 {
+		turn = "b"
+	}
+	phase := PhaseDrop
+	if b.Pieces() == 8 {
+		phase = PhaseMove
+	}
+	return sb.String() + " " + turn + " " + phase
+}
+
+// ParsePosition reads a position string or a printed grid into a board.
+// The history of the board is unknown: Board.M is empty when A is to move
+// and holds a single UnknownAction when B is.
+func ParsePosition(s string) (Board, error) {
+	if strings.Contains(strings.TrimSpace(s), "\n") {
+		return parseGrid(s)
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 3 {
+		return Board{}, fmt.Errorf("%w: want rows, player to move and phase, as in %q", ErrPosition, FormatPosition(Board{}))
+	}
+	rows := strings.Split(fields[0], "/")
+	if len(rows) != Edge {
+		return Board{}, fmt.Errorf("%w: want %d rows separated by /, got %d", ErrPosition, Edge, len(rows))
+	}
+	var a, b uint32
+	for row, text := range rows {
+		if len(text) != Edge {
+			return Board{}, fmt.Errorf("%w: row %d has %d squares", ErrPosition, Edge-row, len(text))
+		}
+		for col := range Edge {
+			sq := row*Edge + col
+			if err := readCell(text[col:col+1], sq, &a, &b); err != nil {
+				return Board{}, err
+			}
+		}
+	}
+	return NewPosition(a, b, fields[1], fields[2])
+}
+
+func readCell(cell string, sq int, a, b *uint32) error {
+	switch cell {
+	case "A", "a":
+		*a |= 1 << sq
+	case "B", "b":
+		*b |= 1 << sq
+	case ".", "·":
+	default:
+		return fmt.Errorf("%w: %q at %s is not A, B or .", ErrPosition, cell, SquareName(sq))
+	}
+	return nil
+}
+
+// The grid printed by the CLI, optionally with its "Board:" header, row and column
+// labels and the "a to move" line of query; a "Position:" line takes precedence.
+// Without either line, the player to move follows from the piece counts, which
+// is ambiguous once all pieces are down.
+func parseGrid(s string) (Board, error) {
+	var a, b uint32
+	turn := ""
+	row := 0
+	for _, line := range strings.Split(s, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0 || fields[0] == "Board:" || strings.Join(fields, "") == "abcde":
+			continue
+		case len(fields) > 1 && fields[0] == "Position:":
+			// Printed alongside the grid, and exact
+			return ParsePosition(strings.Join(fields[1:], " "))
+		case len(fields) >= 3 && fields[1] == "to" && strings.TrimSuffix(fields[2], ":") == "move":
+			turn = fields[0]
+			continue
+		}
+		if len(fields) == Edge+1 && fields[0] == fmt.Sprint(Edge-row) {
+			fields = fields[1:]
+		}
+		if len(fields) != Edge || row == Edge {
+			return Board{}, fmt.Errorf("%w: unexpected line %q", ErrPosition, strings.TrimSpace(line))
+		}
+		for col, cell := range fields {
+			if err := readCell(cell, row*Edge+col, &a, &b); err != nil {
+				return Board{}, err
+			}
+		}
+		row++
+	}
+	if row != Edge {
+		return Board{}, fmt.Errorf("%w: want %d rows, got %d", ErrPosition, Edge, row)
+	}
+
+	na, nb := bits.OnesCount32(a), bits.OnesCount32(b)
+	phase := PhaseDrop
+	if na+nb == 8 {
+		phase = PhaseMove
+	}
+	if turn == "" {
+		switch {
+		case phase == PhaseMove:
+			return Board{}, fmt.Errorf("%w: add a line such as \"a to move\", as all pieces are down", ErrPosition)
+		case na == nb:
+			turn = "a"
+		default:
+			turn = "b"
+		}
+	}
+	return NewPosition(a, b, turn, phase)
+}
+
+// NewPosition checks that a position can arise in a game and returns its board,
+// with an unknown history as ParsePosition. Turn is a or b; phase is drop or move.
+func NewPosition(a, b uint32, turn, phase string) (Board, error) {
+	na, nb := bits.OnesCount32(a), bits.OnesCount32(b)
+	board := Board{A: a, B: b, P: true}
+	switch turn {
+	case "a":
+	case "b":
+		board.M = []Action{UnknownAction}
+	default:
+		return board, fmt.Errorf("%w: player to move must be a or b, not %q", ErrPosition, turn)
+	}
+
+	switch {
+	case a&b != 0:
+		return board, fmt.Errorf("%w: squares held by both players", ErrPosition)
+	case (a|b)>>Size != 0:
+		return board, fmt.Errorf("%w: %w", ErrPosition, ErrOffBoard)
+	case na > 4 || nb > 4:
+		return board, fmt.Errorf("%w: %d pieces for A and %d for B, at most 4 each", ErrPosition, na, nb)
+	case phase != PhaseDrop && phase != PhaseMove:
+		return board, fmt.Errorf("%w: phase must be %s or %s, not %q", ErrPosition, PhaseDrop, PhaseMove, phase)
+	case (phase == PhaseMove) != (na+nb == 8):
+		return board, fmt.Errorf("%w: %s phase with %d pieces", ErrPosition, phase, na+nb)
+	// A drops first, so B has as many pieces before A's drop and one fewer before B's
+	case phase == PhaseDrop && turn == "a" && na != nb,
+		phase == PhaseDrop && turn == "b" && na != nb+1:
+		return board, fmt.Errorf("%w: %d pieces for A and %d for B with %s to move", ErrPosition, na, nb, turn)
+	case IsWin(a) && IsWin(b):
+		return board, fmt.Errorf("%w: both players have won", ErrPosition)
+	case turn == "a" && IsWin(a), turn == "b" && IsWin(b):
+		return board, fmt.Errorf("%w: the player to move has already won", ErrPosition)
+	}
+	return board, nil
+}
+
+// TableBoard returns the board of a table entry, whose a is the player to move.
+// Drops alternate from A, so the player to move has fewer pieces exactly when
+// it is Red; once all pieces are down either can move and A is chosen.
+func TableBoard(mover, other uint32, n int) Board {
+	if n%2 == 1 {
+		return Board{A: other, B: mover, M: []Action{UnknownAction}, P: true}
+	}
+	return Board{A: mover, B: other, P: true}
+}
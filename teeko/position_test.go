@@ -0,0 +1,118 @@
+package teeko
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestPositionRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for game := range 200 {
+		b := Board{P: true}
+		for ply := 0; ply < 40 && !IsWin(b.A) && !IsWin(b.B); {
+			action := Drop(rng.IntN(Size))
+			if b.Pieces() == 8 {
+				action = Action{rng.IntN(Size), rng.IntN(Size)}
+			}
+			next, err := b.Apply(action)
+			if err != nil {
+				continue
+			}
+			b = next
+			ply++
+
+			s := FormatPosition(b)
+			got, err := ParsePosition(s)
+			if err != nil {
+				t.Fatalf("game %d: ParsePosition(%q): %v", game, s, err)
+			}
+			if got.A != b.A || got.B != b.B || len(got.M)%2 != len(b.M)%2 {
+				t.Fatalf("game %d: %q parsed as a=%d b=%d after %d plies, want a=%d b=%d after %d",
+					game, s, got.A, got.B, len(got.M), b.A, b.B, len(b.M))
+			}
+			if again := FormatPosition(got); again != s {
+				t.Fatalf("game %d: %q formatted back as %q", game, s, again)
+			}
+		}
+	}
+}
+
+func TestParseGrid(t *testing.T) {
+	const grid = `
+  Board:
+    5 · · · · B
+    4 B · · · ·
+    3 · · · · ·
+    2 · · · B A
+    1 · · A A ·
+      a b c d e
+`
+	b, err := ParsePosition(grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := FormatPosition(b), "....B/B..../...../...BA/..AA. a drop"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// The exact position line wins over the grid and its piece counts
+	b, err = ParsePosition(grid + "  Position: ....B/...../...../...BA/..AA. b drop\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := FormatPosition(b), "....B/...../...../...BA/..AA. b drop"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParsePositionErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"...../...../...../...../..... a",
+		"...../...../...../..... a drop",
+		"...../...../...../...../.... a drop",
+		"...../...../...../...../....X a drop",
+		"...../...../...../...../..... c drop",
+		"...../...../...../...../..... a move",
+		"...../...../...../...../..... b drop",
+		"AAAAA/BBBB./...../...../..... b drop",
+		"AAAA./BBBB./...../...../..... a move",
+		"AAAA./B..../B..../B..../B.... a move",
+	} {
+		if _, err := ParsePosition(s); !errors.Is(err, ErrPosition) {
+			t.Errorf("ParsePosition(%q) = %v, want ErrPosition", s, err)
+		}
+	}
+}
+
+func TestPositionHistory(t *testing.T) {
+	b, err := ParsePosition("A..../...../...../...../..... b drop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.M) != 1 || b.M[0] != UnknownAction {
+		t.Fatalf("history %v, want a single unknown action", b.M)
+	}
+	// Not a drop on a5 that could be taken back
+	if _, err := b.Undo(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Undo: %v, want ErrNoHistory", err)
+	}
+	if next, err := b.Apply(Drop(24)); err != nil || next.B != 1<<24 || len(next.M) != 2 {
+		t.Errorf("Red dropping on e1: %+v, %v", next, err)
+	}
+	if got := UnknownAction.String(); got != "?" {
+		t.Errorf("UnknownAction prints as %q", got)
+	}
+
+	// The history survives JSON, as in Board.m from model.ts
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Board
+	if err := json.Unmarshal(data, &back); err != nil || len(back.M) != 1 || back.M[0] != UnknownAction {
+		t.Errorf("%s read back as %v, %v", data, back.M, err)
+	}
+}
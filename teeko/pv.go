@@ -0,0 +1,46 @@
+package teeko
+
+// Ply is a move of a principal variation with the board it leads to
+type Ply struct {
+	Move
+	Board Board
+}
+
+// PrincipalVariation follows best play from board: the whole forced line when
+// the position is won or lost, otherwise at most plies moves. Among equally
+// scored moves the first in GenerateMoves order is chosen, so lines are reproducible.
+func PrincipalVariation(t ScoreTable, board Board, plies int) []Ply {
+	mover, other := board.Mover()
+	if d, forced := PositionDistance(t.Lookup(mover, other, board.Pieces())); forced {
+		plies = d
+	}
+
+	var line []Ply
+	for len(line) < plies {
+		if IsWin(board.A) || IsWin(board.B) {
+			break
+		}
+		moves := GenerateMoves(t, board)
+		if len(moves) == 0 {
+			break
+		}
+		best := moves[0]
+		for _, m := range moves[1:] {
+			if m.Score > best.Score {
+				best = m
+			}
+		}
+		next, err := board.Apply(best.Action)
+		if err != nil {
+			panic(err)
+		}
+		board = next
+		line = append(line, Ply{best, board})
+	}
+	return line
+}
+
+// Pieces returns the number of pieces on the board
+func (b Board) Pieces() int {
+	return Position{b.A, b.B}.Pieces()
+}
@@ -0,0 +1,157 @@
+package teeko
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// Rule violations, wrapped in a *RuleError
+var (
+	ErrOffBoard    = errors.New("square is off the board")
+	ErrOccupied    = errors.New("square is occupied")
+	ErrNotOwnPiece = errors.New("the player to move has no piece there")
+	ErrNotAdjacent = errors.New("squares are not adjacent")
+	ErrMustDrop    = errors.New("pieces must be dropped until each player has four")
+	ErrMustMove    = errors.New("all pieces are on the board and can only move")
+	ErrGameOver    = errors.New("the game is already won")
+	ErrNoHistory   = errors.New("there is no action to undo")
+	ErrHistory     = errors.New("history does not extend the accepted one")
+	ErrMismatch    = errors.New("pieces do not match the history")
+)
+
+// RuleError reports the first violation found in a game history
+type RuleError struct {
+	Ply    int     // index in Board.M of the offending action, or its length for whole-board errors
+	Action *Action // nil for whole-board errors
+	Err    error   // one of the Err* violations above
+}
+
+func (e *RuleError) Error() string {
+	if e.Action == nil {
+		return fmt.Sprintf("ply %d: %v", e.Ply, e.Err)
+	}
+	return fmt.Sprintf("ply %d, %v: %v", e.Ply, e.Action, e.Err)
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// Apply plays an action for the player to move, like computePlace and computeMove
+// in model.ts but also enforcing the phases, adjacency and the end of the game
+func (b Board) Apply(action Action) (Board, error) {
+	fail := func(err error) (Board, error) {
+		return b, &RuleError{Ply: len(b.M), Action: &action, Err: err}
+	}
+	mover, other := b.Mover()
+	switch {
+	case IsWin(b.A) || IsWin(b.B):
+		return fail(ErrGameOver)
+	case action.To < 0 || action.To >= Size || action.From >= Size:
+		return fail(ErrOffBoard)
+	case (mover|other)&(1<<action.To) != 0:
+		return fail(ErrOccupied)
+	}
+
+	if action.IsDrop() {
+		if bits.OnesCount32(mover) >= 4 {
+			return fail(ErrMustMove)
+		}
+		mover |= 1 << action.To
+	} else {
+		switch {
+		case bits.OnesCount32(mover) < 4:
+			return fail(ErrMustDrop)
+		case mover&(1<<action.From) == 0:
+			return fail(ErrNotOwnPiece)
+		case Neighs[action.From]&(1<<action.To) == 0:
+			return fail(ErrNotAdjacent)
+		}
+		mover ^= 1<<action.From | 1<<action.To
+	}
+
+	next := Board{M: append(b.M[:len(b.M):len(b.M)], action), P: b.P}
+	if len(b.M)%2 == 0 {
+		next.A, next.B = mover, other
+	} else {
+		next.A, next.B = other, mover
+	}
+	return next, nil
+}
+
+// Undo takes back the last action, like computeUndo in model.ts
+func (b Board) Undo() (Board, error) {
+	if len(b.M) == 0 {
+		return b, &RuleError{Ply: 0, Err: ErrNoHistory}
+	}
+	last := b.M[len(b.M)-1]
+	if last == UnknownAction {
+		return b, &RuleError{Ply: len(b.M) - 1, Err: ErrNoHistory}
+	}
+	prev := Board{A: b.A, B: b.B, M: b.M[: len(b.M)-1 : len(b.M)-1], P: b.P}
+	target := &prev.A
+	if len(prev.M)%2 == 1 {
+		target = &prev.B
+	}
+	*target &^= 1 << last.To
+	if !last.IsDrop() {
+		*target |= 1 << last.From
+	}
+	return prev, nil
+}
+
+// Replay plays a history from the empty board
+func Replay(history []Action) (Board, error) {
+	b := Board{P: true}
+	for _, action := range history {
+		var err error
+		if b, err = b.Apply(action); err != nil {
+			return b, err
+		}
+	}
+	return b, nil
+}
+
+// CheckHistory validates a board submitted after the accepted board prev:
+// a restart from the empty board, an undo of prev's last action, or prev's history
+// extended by legal actions. The pieces must match the replayed history.
+func CheckHistory(prev, next Board) error {
+	var want Board
+	switch {
+	case len(next.M) == 0:
+		// Restart
+	case len(next.M) == len(prev.M)-1:
+		want, _ = prev.Undo()
+		if i := divergence(want.M, next.M); i >= 0 {
+			return &RuleError{Ply: i, Err: ErrHistory}
+		}
+	case len(next.M) >= len(prev.M):
+		if i := divergence(prev.M, next.M[:len(prev.M)]); i >= 0 {
+			return &RuleError{Ply: i, Err: ErrHistory}
+		}
+		want = prev
+		for _, action := range next.M[len(prev.M):] {
+			var err error
+			if want, err = want.Apply(action); err != nil {
+				return err
+			}
+		}
+	default:
+		return &RuleError{Ply: len(next.M), Err: ErrHistory}
+	}
+	if next.A != want.A || next.B != want.B {
+		return &RuleError{Ply: len(next.M), Err: ErrMismatch}
+	}
+	return nil
+}
+
+// Index of the first difference between two histories of the same length, or -1
+func divergence(x, y []Action) int {
+	for i := range x {
+		if x[i] != y[i] {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,111 @@
+package teeko
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestActionJSON(t *testing.T) {
+	for _, tc := range []struct {
+		json string
+		want Action
+	}{
+		{"12", Drop(12)},
+		{"[12, 7]", Action{12, 7}},
+	} {
+		var a Action
+		if err := json.Unmarshal([]byte(tc.json), &a); err != nil || a != tc.want {
+			t.Errorf("%s: got %v, %v, want %v", tc.json, a, err, tc.want)
+		}
+	}
+
+	var a Action
+	if err := json.Unmarshal([]byte("[-1, 7]"), &a); !errors.Is(err, ErrOffBoard) {
+		t.Errorf("move from -1: error %v, want ErrOffBoard", err)
+	}
+	if err := json.Unmarshal([]byte(`"c3"`), &a); err == nil {
+		t.Errorf("notation accepted as %v", a)
+	}
+}
+
+func parseActions(t *testing.T, moves ...string) []Action {
+	t.Helper()
+	var actions []Action
+	for _, m := range moves {
+		a, err := ParseAction(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+// Build a board from a history in notation
+func replay(t *testing.T, moves ...string) Board {
+	t.Helper()
+	b, err := Replay(parseActions(t, moves...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestCheckHistory(t *testing.T) {
+	opening := []string{"c3", "c4", "b3", "d4", "b4", "b2", "d2", "a5"}
+	prev := replay(t, opening...)
+	undone, _ := prev.Undo()
+
+	withPieces := func(b Board, a, bb uint32) Board {
+		b.A, b.B = a, bb
+		return b
+	}
+	// Extend the history with an action from one square to another, without checking it
+	extend := func(from, to string) Board {
+		action := Action{From: -1}
+		if from != "" {
+			action.From, _ = ParseSquare(from)
+		}
+		action.To, _ = ParseSquare(to)
+		next := prev
+		next.M = append(prev.M[:len(prev.M):len(prev.M)], action)
+		return next
+	}
+
+	for _, tc := range []struct {
+		name string
+		next Board
+		err  error // nil for accepted histories
+		ply  int
+	}{
+		{"same", prev, nil, 0},
+		{"restart", Board{P: true}, nil, 0},
+		{"one more", replay(t, append(opening, "d2-c2")...), nil, 0},
+		{"two more", replay(t, append(opening, "d2-c2", "c4-d3")...), nil, 0},
+		{"undo", undone, nil, 0},
+		{"undo with other pieces", withPieces(undone, undone.A, prev.B), ErrMismatch, 7},
+		{"undo then another action", replay(t, append(opening[:6:6], "e5")...), ErrHistory, 6},
+		{"last action replaced", replay(t, append(opening[:7:7], "e5")...), ErrHistory, 7},
+		{"two undos", replay(t, opening[:6]...), ErrHistory, 6},
+		{"rewritten history", replay(t, append([]string{"e1"}, opening[1:]...)...), ErrHistory, 0},
+		{"illegal extension", extend("c3", "c5"), ErrNotAdjacent, 8},
+		{"drop in the move phase", extend("", "e1"), ErrMustMove, 8},
+		{"move of a Red piece", extend("c4", "c5"), ErrNotOwnPiece, 8},
+		{"pieces not matching", withPieces(prev, prev.A, prev.B|1<<24), ErrMismatch, 8},
+	} {
+		err := CheckHistory(prev, tc.next)
+		if tc.err == nil {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+			continue
+		}
+		var re *RuleError
+		if !errors.Is(err, tc.err) || !errors.As(err, &re) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.err)
+		} else if re.Ply != tc.ply {
+			t.Errorf("%s: error at ply %d, want %d", tc.name, re.Ply, tc.ply)
+		}
+	}
+}
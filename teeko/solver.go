@@ -0,0 +1,576 @@
+package teeko
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Solver computes complete score tables for every position by retrograde analysis.
+// Scores[n][g] is the score of Goedel index g with n pieces, from the perspective
+// of the player to move (A). Solvers from NewCanonicalSolver index their tables
+// by canonical rank instead.
+type Solver struct {
+	Scores  [9][]int8
+	Workers int       // number of goroutines, defaults to runtime.NumCPU()
+	Log     io.Writer // progress output, defaults to io.Discard
+
+	// Play phase checkpoints, disabled when CheckpointFile is empty
+	CheckpointFile  string
+	CheckpointEvery time.Duration // minimum time between checkpoints
+	lastCheckpoint  time.Time
+	resumed         *playProgress
+
+	// LowMemory replaces the position cache (about 750 MB) with occupancy masks
+	// and colour patterns cached separately (about 7 MB), decoding positions on the fly.
+	// Only solvers from NewSolver support it; canonical solvers always cache positions.
+	LowMemory bool
+
+	// Precomputed positions (a, b pairs) for each piece count
+	posCache      [9][]uint64 // packed as (a << 32) | b
+	occCache      [9][]uint32 // LowMemory: occupied squares by position number
+	patCache      [9][]uint32 // LowMemory: colour pattern by pattern number
+	posCacheReady bool
+
+	// Canonical mode: rank index of canonical positions, nil for full tables
+	canon *DB
+}
+
+// NewSolver allocates full score tables for all piece counts
+func NewSolver() *Solver {
+	s := &Solver{Workers: runtime.NumCPU(), Log: io.Discard, CheckpointEvery: 10 * time.Minute, lastCheckpoint: time.Now()}
+	for i := range 9 {
+		s.Scores[i] = make([]int8, Configs[i])
+	}
+	return s
+}
+
+// NewCanonicalSolver allocates tables for canonical positions only, about 8x
+// smaller than full ones. Every position is solved through its canonical form,
+// giving the same scores as solving full tables then compressing them.
+func NewCanonicalSolver() *Solver {
+	s := &Solver{Workers: runtime.NumCPU(), Log: io.Discard, CheckpointEvery: 10 * time.Minute, lastCheckpoint: time.Now()}
+	s.canon = &DB{}
+	s.canon.BuildIndex()
+	for n := range 9 {
+		s.canon.checkpointsFromRanks(n)
+		s.Scores[n] = make([]int8, s.canon.Counts[n])
+
+		// Positions by rank, replacing the full position cache
+		s.posCache[n] = make([]uint64, 0, s.canon.Counts[n])
+		for w, word := range s.canon.canon[n] {
+			for ; word != 0; word &= word - 1 {
+				a, b := Degoedel(w<<6+bits.TrailingZeros64(word), n)
+				s.posCache[n] = append(s.posCache[n], packPos(a, b))
+			}
+		}
+	}
+	s.posCacheReady = true
+	return s
+}
+
+// Index of a position in the score tables
+func (s *Solver) index(a, b uint32, n int) int {
+	if s.canon != nil {
+		return s.canon.Rank(n, Canonical(a, b, n))
+	}
+	return Goedel(a, b, n)
+}
+
+func (s *Solver) logf(format string, args ...any) {
+	fmt.Fprintf(s.Log, format, args...)
+}
+
+// Initialize position cache for faster lookups
+func (s *Solver) initPosCache() {
+	if s.posCacheReady {
+		return
+	}
+	s.logf("Precomputing position cache…\n")
+	start := time.Now()
+	if s.LowMemory {
+		s.initCompactCache()
+		s.logf("  Compact position cache ready in %v\n", time.Since(start).Round(time.Millisecond))
+		return
+	}
+
+	numWorkers := s.Workers
+	var wg sync.WaitGroup
+
+	for n := range 9 {
+		s.posCache[n] = make([]uint64, Configs[n])
+		chunkSize := (Configs[n] + numWorkers - 1) / numWorkers
+
+		for w := 0; w < numWorkers; w++ {
+			wg.Add(1)
+			start := w * chunkSize
+			end := min(start+chunkSize, Configs[n])
+			go func(n, start, end int) {
+				defer wg.Done()
+				for g := start; g < end; g++ {
+					a, b := Degoedel(g, n)
+					s.posCache[n][g] = packPos(a, b)
+				}
+			}(n, start, end)
+		}
+		wg.Wait()
+	}
+
+	s.posCacheReady = true
+	s.logf("  Position cache ready in %v\n", time.Since(start).Round(time.Millisecond))
+}
+
+// Cache occupancy masks and colour patterns separately.
+// A Goedel index is posNum + Positions[n]*patNum, so decoding index patNum*Positions[n]+posNum
+// gives the occupancy for posNum, and decoding patNum*Positions[n] gives pattern patNum.
+func (s *Solver) initCompactCache() {
+	for n := range 9 {
+		s.occCache[n] = make([]uint32, Positions[n])
+		for posNum := range Positions[n] {
+			a, b := Degoedel(posNum, n)
+			s.occCache[n][posNum] = a | b
+		}
+		s.patCache[n] = make([]uint32, Patterns[n])
+		for patNum := range Patterns[n] {
+			_, b := Degoedel(patNum*Positions[n], n)
+			// The lowest occupied square holds pattern bit n-1
+			pat, patBit := uint32(0), uint32(1<<n)>>1
+			for occ := s.occCache[n][0]; occ != 0; occ &= occ - 1 {
+				if b&(occ&-occ) != 0 {
+					pat |= patBit
+				}
+				patBit >>= 1
+			}
+			s.patCache[n][patNum] = pat
+		}
+	}
+	s.posCacheReady = true
+}
+
+// Fast position lookup from cache
+func (s *Solver) getPos(g, n int) (a, b uint32) {
+	if s.posCache[n] != nil {
+		return unpackPos(s.posCache[n][g])
+	}
+	occ := s.occCache[n][g%Positions[n]]
+	pat := s.patCache[n][g/Positions[n]]
+	for patBit := uint32(1<<n) >> 1; occ != 0; patBit >>= 1 {
+		sq := occ & -occ
+		occ ^= sq
+		if pat&patBit != 0 {
+			b |= sq
+		} else {
+			a |= sq
+		}
+	}
+	return
+}
+
+// Append the indices of positions reachable by moving one of A's pieces,
+// seen from B's perspective
+func playSuccessors(a, b uint32, index func(a, b uint32, n int) int, neighbors []int) []int {
+	ab := a | b
+	for p := a; p != 0; {
+		piece := p & -p
+		p ^= piece
+		pos := bits.TrailingZeros32(piece)
+		for dests := Neighs[pos] &^ ab; dests != 0; {
+			dest := dests & -dests
+			dests ^= dest
+			neighbors = append(neighbors, index(b, (a^piece)|dest, 8))
+		}
+	}
+	return neighbors
+}
+
+// Append the indices of positions reachable by dropping one of A's pieces,
+// seen from B's perspective
+func dropSuccessors(a, b uint32, n int, index func(a, b uint32, n int) int, neighbors []int) []int {
+	ab := a | b
+	for sq := uint32(1); sq < (1 << Size); sq <<= 1 {
+		if sq&ab == 0 {
+			neighbors = append(neighbors, index(b, a|sq, n+1))
+		}
+	}
+	return neighbors
+}
+
+// Score combination for minimax
+func bestScore(neighbors []int, table []int8) int8 {
+	result := ScoreNone
+	for _, n := range neighbors {
+		s := table[n]
+		if s == ScoreNone {
+			s = ScoreTie
+		} else if s < ScoreBWin || s > ScoreAWin {
+			continue
+		}
+		s = -s // opponent's perspective
+		// Only decay win/loss scores, not heuristics
+		if s > ScoreHeuristicMax {
+			s--
+		} else if s < -ScoreHeuristicMax {
+			s++
+		}
+		if result == ScoreNone || s > result {
+			result = s
+		}
+	}
+	if result == ScoreNone {
+		return ScoreTie
+	}
+	return result
+}
+
+// ComputePlay solves the movement phase (8 pieces on the board).
+// When CheckpointFile is set, progress is saved there periodically,
+// and a checkpoint loaded with Resume is continued from.
+func (s *Solver) ComputePlay() error {
+	if s.LowMemory && s.canon != nil {
+		return fmt.Errorf("low memory mode needs full tables, see NewSolver")
+	}
+	s.logf("Computing play phase…\n")
+	start := time.Now()
+
+	// Precompute positions first
+	s.initPosCache()
+
+	table := s.Scores[8]
+	s.logf("  Using %d workers\n", s.Workers)
+
+	progress := s.resumed
+	if progress == nil {
+		s.initPlay(table)
+		progress = &playProgress{phaseRetrograde, int(ScoreAWin)}
+	} else {
+		s.logf("  Resuming from checkpoint (%s)\n", progress)
+	}
+
+	if progress.phase == phaseRetrograde {
+		if err := s.retrograde(table, int8(progress.pos)); err != nil {
+			return err
+		}
+		progress = &playProgress{phaseHeuristics, 0}
+	}
+	if progress.phase == phaseHeuristics {
+		if err := s.heuristics(table, progress.pos); err != nil {
+			return err
+		}
+		if err := s.checkpoint(playProgress{phaseDone, 0}, true); err != nil {
+			return err
+		}
+	}
+	s.resumed = nil
+
+	s.countStats("Play", table)
+	s.logf("  Play phase completed in %v\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Initial scores: immediate wins and illegal positions
+func (s *Solver) initPlay(table []int8) {
+	numWorkers := s.Workers
+	s.logf("  Initializing %d positions…\n", len(table))
+	var illegal, aWins, bWins atomic.Int64
+	var wg sync.WaitGroup
+	chunkSize := (len(table) + numWorkers - 1) / numWorkers
+
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		startIdx := w * chunkSize
+		endIdx := min(startIdx+chunkSize, len(table))
+		go func(startIdx, endIdx int) {
+			defer wg.Done()
+			localIllegal, localAWins, localBWins := int64(0), int64(0), int64(0)
+			for g := startIdx; g < endIdx; g++ {
+				a, b := s.getPos(g, 8)
+				aWin, bWin := IsWin(a), IsWin(b)
+				switch {
+				case aWin && bWin:
+					table[g] = ScoreIllegal
+					localIllegal++
+				case bWin:
+					table[g] = ScoreBWin
+					localBWins++
+				case aWin:
+					table[g] = ScoreAWin
+					localAWins++
+				default:
+					table[g] = ScoreTie
+				}
+			}
+			illegal.Add(localIllegal)
+			aWins.Add(localAWins)
+			bWins.Add(localBWins)
+		}(startIdx, endIdx)
+	}
+	wg.Wait()
+	s.logf("  Initial: %d illegal, %d A wins, %d B wins\n", illegal.Load(), aWins.Load(), bWins.Load())
+
+}
+
+// Retrograde analysis from level down to convergence
+func (s *Solver) retrograde(table []int8, from int8) error {
+	numWorkers := s.Workers
+	var wg sync.WaitGroup
+	chunkSize := (len(table) + numWorkers - 1) / numWorkers
+
+	s.logf("  Retrograde analysis…\n")
+	snapshot := make([]int8, len(table))
+	// Phase 1 only reads snapshot and records its findings in bitsets with atomic ORs;
+	// phase 2 then writes each table entry from the worker owning it.
+	// No memory is written by two workers, so results do not depend on scheduling.
+	wins := make([]uint64, (len(table)+63)/64)  // positions winning in level-1
+	marks := make([]uint64, (len(table)+63)/64) // drawn positions to re-evaluate
+	maxLevel := 0
+
+	for level := from; level > 0; level-- {
+		copy(snapshot, table)
+		clear(wins)
+		clear(marks)
+		var changed atomic.Bool
+
+		// Phase 1: Generate unmoves (parallel)
+		for w := 0; w < numWorkers; w++ {
+			wg.Add(1)
+			startIdx := w * chunkSize
+			endIdx := min(startIdx+chunkSize, len(table))
+			go func(startIdx, endIdx int) {
+				defer wg.Done()
+				for g := startIdx; g < endIdx; g++ {
+					if snapshot[g] == ScoreTie {
+						continue
+					}
+					ps := -snapshot[g]
+					if ps != level && ps != -level {
+						continue
+					}
+					a, b := s.getPos(g, 8)
+
+					// Generate unmoves (predecessor positions)
+					ab := a | b
+					for p := b; p != 0; {
+						piece := p & -p
+						p ^= piece
+						pos := bits.TrailingZeros32(piece)
+						for dests := Neighs[pos] &^ ab; dests != 0; {
+							dest := dests & -dests
+							dests ^= dest
+							n := s.index((b^piece)|dest, a, 8)
+							if ps == level {
+								if psn := snapshot[n]; psn < ps-1 && psn > ScoreBWin {
+									atomic.OrUint64(&wins[n>>6], 1<<(n&63))
+								}
+							} else if snapshot[n] == ScoreTie {
+								atomic.OrUint64(&marks[n>>6], 1<<(n&63))
+							}
+						}
+					}
+				}
+			}(startIdx, endIdx)
+		}
+		wg.Wait()
+
+		// Phase 2: Apply wins and process marked positions (parallel)
+		for w := 0; w < numWorkers; w++ {
+			wg.Add(1)
+			startIdx := w * chunkSize
+			endIdx := min(startIdx+chunkSize, len(table))
+			go func(startIdx, endIdx int) {
+				defer wg.Done()
+				neighbors := make([]int, 0, 32) // pre-allocated per worker
+				index := s.index
+				for g := startIdx; g < endIdx; g++ {
+					bit := uint64(1) << (g & 63)
+					if marks[g>>6]&bit != 0 {
+						a, b := s.getPos(g, 8)
+						neighbors = playSuccessors(a, b, index, neighbors[:0]) // reset without allocation
+						if ns := bestScore(neighbors, snapshot); ns != ScoreTie && ns != ScoreNone {
+							table[g] = ns
+							changed.Store(true)
+						} else {
+							table[g] = ScoreTie
+						}
+					} else if wins[g>>6]&bit != 0 {
+						table[g] = level - 1
+						changed.Store(true)
+					}
+				}
+			}(startIdx, endIdx)
+		}
+		wg.Wait()
+
+		if !changed.Load() {
+			maxLevel = int(ScoreAWin - level)
+			s.logf("  Converged at depth %d\n", maxLevel)
+			break
+		}
+		if level%10 == 0 {
+			s.logf("    Level %d…\n", level)
+		}
+		if err := s.checkpoint(playProgress{phaseRetrograde, int(level - 1)}, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Heuristic batch size, the granularity of heuristic-phase checkpoints
+const heuristicBatch = 1 << 20
+
+// Compute heuristics for drawn positions from Goedel index from onwards
+func (s *Solver) heuristics(table []int8, from int) error {
+	numWorkers := s.Workers
+	var wg sync.WaitGroup
+
+	s.logf("  Computing heuristics for draws…\n")
+	var draws, progress atomic.Int64
+	// Count total draws first
+	var totalDraws int64
+	for g := from; g < len(table); g++ {
+		if table[g] == ScoreTie {
+			totalDraws++
+		}
+	}
+	s.logf("    0%% (0/%d)\r", totalDraws)
+	for batch := from; batch < len(table); batch += heuristicBatch {
+		batchEnd := min(batch+heuristicBatch, len(table))
+		chunkSize := (batchEnd - batch + numWorkers - 1) / numWorkers
+		for w := 0; w < numWorkers; w++ {
+			wg.Add(1)
+			startIdx := batch + w*chunkSize
+			endIdx := min(startIdx+chunkSize, batchEnd)
+			go func(startIdx, endIdx int) {
+				defer wg.Done()
+				localDraws := int64(0)
+				for g := startIdx; g < endIdx; g++ {
+					if table[g] == ScoreTie {
+						a, b := s.getPos(g, 8)
+						table[g] = Heuristic(a, b)
+						localDraws++
+						if localDraws%1000 == 0 {
+							cur := progress.Add(1000)
+							pct := cur * 100 / totalDraws
+							s.logf("    %d%% (%d/%d)\r", pct, cur, totalDraws)
+						}
+					}
+				}
+				draws.Add(localDraws)
+				progress.Add(localDraws % 1000)
+			}(startIdx, endIdx)
+		}
+		wg.Wait()
+		if err := s.checkpoint(playProgress{phaseHeuristics, batchEnd}, false); err != nil {
+			return err
+		}
+	}
+	s.logf("    100%% (%d/%d)\n", draws.Load(), totalDraws)
+	return nil
+}
+
+// ComputeDrop solves the drop phase (0 to 7 pieces) from the play phase results
+func (s *Solver) ComputeDrop() {
+	s.logf("Computing drop phase…\n")
+	start := time.Now()
+	s.initPosCache()
+	numWorkers := s.Workers
+
+	// 7 pieces - check for B wins, then propagate from play
+	table7 := s.Scores[7]
+	s.logf("  Processing 7 pieces (%d positions)…\n", len(table7))
+	var bWins atomic.Int64
+	var wg sync.WaitGroup
+	chunkSize := (len(table7) + numWorkers - 1) / numWorkers
+
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		startIdx := w * chunkSize
+		endIdx := min(startIdx+chunkSize, len(table7))
+		go func(startIdx, endIdx int) {
+			defer wg.Done()
+			localBWins := int64(0)
+			for g := startIdx; g < endIdx; g++ {
+				_, b := s.getPos(g, 7)
+				if IsWin(b) {
+					table7[g] = ScoreBWin
+					localBWins++
+				}
+			}
+			bWins.Add(localBWins)
+		}(startIdx, endIdx)
+	}
+	wg.Wait()
+	s.logf("    %d immediate B wins\n", bWins.Load())
+	s.propagateDrop(7)
+
+	// 6 to 0 pieces
+	for n := 6; n >= 0; n-- {
+		s.logf("  Processing %d pieces (%d positions)…\n", n, len(s.Scores[n]))
+		s.propagateDrop(n)
+	}
+
+	s.logf("  Initial position score: %d\n", s.Scores[0][0])
+	s.logf("  Drop phase completed in %v\n", time.Since(start).Round(time.Millisecond))
+}
+
+func (s *Solver) propagateDrop(n int) {
+	current, next := s.Scores[n], s.Scores[n+1]
+	numWorkers := s.Workers
+	var wg sync.WaitGroup
+	chunkSize := (len(current) + numWorkers - 1) / numWorkers
+
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		startIdx := w * chunkSize
+		endIdx := min(startIdx+chunkSize, len(current))
+		go func(startIdx, endIdx int) {
+			defer wg.Done()
+			neighbors := make([]int, 0, Size) // pre-allocated per worker
+			index := s.index
+			for g := startIdx; g < endIdx; g++ {
+				if current[g] != ScoreTie {
+					continue // preserve wins detected earlier
+				}
+				a, b := s.getPos(g, n)
+				neighbors = dropSuccessors(a, b, n, index, neighbors[:0]) // reset without allocation
+				current[g] = bestScore(neighbors, next)
+			}
+		}(startIdx, endIdx)
+	}
+	wg.Wait()
+	s.countStats(fmt.Sprintf("Drop %d", n), current)
+}
+
+func (s *Solver) countStats(label string, table []int8) {
+	ties, aWins, bWins, aAdvantage, bAdvantage := 0, 0, 0, 0, 0
+	for _, s := range table {
+		switch {
+		case s == ScoreTie:
+			ties++
+		case s > ScoreHeuristicMax:
+			aWins++
+		case s < -ScoreHeuristicMax:
+			bWins++
+		case s > 0:
+			aAdvantage++
+		case s < 0:
+			bAdvantage++
+		}
+	}
+	s.logf("  %s: %d draws (%d A+, %d even, %d B+), %d A wins, %d B wins\n",
+		label, aAdvantage+ties+bAdvantage, aAdvantage, ties, bAdvantage, aWins, bWins)
+}
+
+// LongestWins finds the longest forced win for each side across all tables.
+// Either result is nil when that side has no forced win.
+func (s *Solver) LongestWins() (aWin, bWin *Win) {
+	s.logf("Finding longest forced wins…\n")
+	if s.canon != nil {
+		return longestWins(&s.Scores, s.canon.Unrank)
+	}
+	return longestWins(&s.Scores, func(n, g int) int { return g })
+}
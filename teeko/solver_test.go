@@ -0,0 +1,47 @@
+package teeko
+
+import (
+	"io"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// A solver with tables up to 5 pieces, the 5-piece one scored at random
+func dropSolver(workers int) *Solver {
+	s := &Solver{Workers: workers, Log: io.Discard, LowMemory: true}
+	for n := range 5 {
+		s.Scores[n] = make([]int8, Configs[n])
+	}
+	rng := rand.New(rand.NewPCG(3, 4))
+	s.Scores[5] = make([]int8, Configs[5])
+	for g := range s.Scores[5] {
+		if _, b := Degoedel(g, 5); IsWin(b) {
+			s.Scores[5][g] = ScoreBWin
+		} else {
+			s.Scores[5][g] = int8(rng.IntN(2*int(ScoreAWin)+1) - int(ScoreAWin))
+		}
+	}
+	s.initPosCache()
+	return s
+}
+
+func TestPropagateDropWorkers(t *testing.T) {
+	want := dropSolver(1)
+	for n := 4; n >= 0; n-- {
+		want.propagateDrop(n)
+	}
+	// Chunks of uneven sizes, some tables smaller than the worker count
+	for _, workers := range []int{2, 7, 64} {
+		s := dropSolver(workers)
+		for n := 4; n >= 0; n-- {
+			s.propagateDrop(n)
+			if !slices.Equal(s.Scores[n], want.Scores[n]) {
+				t.Fatalf("%d workers: %d-piece scores differ from a single worker", workers, n)
+			}
+		}
+	}
+	if want.Scores[0][0] == ScoreTie {
+		t.Error("empty board left a plain draw, scores did not propagate")
+	}
+}
@@ -0,0 +1,44 @@
+package teeko
+
+import "math/bits"
+
+// D4 symmetry transformation tables (8 symmetries of the square)
+// SymTables[sym][pos] = new position after applying symmetry
+var SymTables = [8][Size]int{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24}, // identity
+	{20, 15, 10, 5, 0, 21, 16, 11, 6, 1, 22, 17, 12, 7, 2, 23, 18, 13, 8, 3, 24, 19, 14, 9, 4}, // rot90 CCW
+	{24, 23, 22, 21, 20, 19, 18, 17, 16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0}, // rot180
+	{4, 9, 14, 19, 24, 3, 8, 13, 18, 23, 2, 7, 12, 17, 22, 1, 6, 11, 16, 21, 0, 5, 10, 15, 20}, // rot270 CCW
+	{4, 3, 2, 1, 0, 9, 8, 7, 6, 5, 14, 13, 12, 11, 10, 19, 18, 17, 16, 15, 24, 23, 22, 21, 20}, // flip H
+	{20, 21, 22, 23, 24, 15, 16, 17, 18, 19, 10, 11, 12, 13, 14, 5, 6, 7, 8, 9, 0, 1, 2, 3, 4}, // flip V
+	{0, 5, 10, 15, 20, 1, 6, 11, 16, 21, 2, 7, 12, 17, 22, 3, 8, 13, 18, 23, 4, 9, 14, 19, 24}, // reflect main diagonal
+	{24, 19, 14, 9, 4, 23, 18, 13, 8, 3, 22, 17, 12, 7, 2, 21, 16, 11, 6, 1, 20, 15, 10, 5, 0}, // reflect anti-diagonal
+}
+
+// Transform a bitmask using symmetry table
+func TransformMask(mask uint32, sym int) uint32 {
+	var result uint32
+	for p := mask; p != 0; {
+		bit := p & -p
+		p ^= bit
+		result |= 1 << SymTables[sym][bits.TrailingZeros32(bit)]
+	}
+	return result
+}
+
+// Find canonical form (minimum Goedel index among all 8 variants)
+func Canonical(a, b uint32, n int) int {
+	minG := Goedel(a, b, n)
+	for s := 1; s < 8; s++ {
+		if g := Goedel(TransformMask(a, s), TransformMask(b, s), n); g < minG {
+			minG = g
+		}
+	}
+	return minG
+}
+
+// Check if a Goedel index is canonical
+func IsCanonical(g, n int) bool {
+	a, b := Degoedel(g, n)
+	return Canonical(a, b, n) == g
+}
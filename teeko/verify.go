@@ -0,0 +1,107 @@
+package teeko
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Mismatch is a position whose stored score differs from the one derived from its successors
+type Mismatch struct {
+	A, B     uint32
+	Pieces   int
+	Stored   int8
+	Expected int8
+}
+
+// Like bestScore, but counting heuristic scores as ties, as they are during retrograde analysis
+func forcedScore(neighbors []int, table []int8) int8 {
+	result := ScoreNone
+	for _, n := range neighbors {
+		s := table[n]
+		if s < ScoreBWin || s > ScoreAWin {
+			continue
+		}
+		if s >= -ScoreHeuristicMax && s <= ScoreHeuristicMax {
+			s = ScoreTie
+		}
+		s = -s
+		if s > ScoreHeuristicMax {
+			s--
+		} else if s < -ScoreHeuristicMax {
+			s++
+		}
+		if result == ScoreNone || s > result {
+			result = s
+		}
+	}
+	if result == ScoreNone {
+		return ScoreTie
+	}
+	return result
+}
+
+// Score the solver assigns to a position given the final scores of its successors
+func expectedScore(a, b uint32, n int, full *FullDB, neighbors []int) int8 {
+	if n == 8 {
+		aWin, bWin := IsWin(a), IsWin(b)
+		switch {
+		case aWin && bWin:
+			return ScoreIllegal
+		case bWin:
+			return ScoreBWin
+		case aWin:
+			return ScoreAWin
+		}
+		if s := forcedScore(playSuccessors(a, b, Goedel, neighbors), full.Scores[8]); s > ScoreHeuristicMax || s < -ScoreHeuristicMax {
+			return s
+		}
+		return Heuristic(a, b)
+	}
+	if n == 7 && IsWin(b) {
+		return ScoreBWin
+	}
+	return bestScore(dropSuccessors(a, b, n, Goedel, neighbors), full.Scores[n+1])
+}
+
+// Verify re-derives the score of every canonical position from its successors,
+// following the rules of ComputePlay and ComputeDrop, and calls report for each
+// position whose stored score differs. Mismatches are reported in Goedel order.
+// Returns the number of mismatches.
+func (db *DB) Verify(report func(Mismatch)) int {
+	full := db.Expand()
+	numWorkers := runtime.NumCPU()
+	total := 0
+
+	for n := range 9 {
+		chunkSize := (Configs[n] + numWorkers - 1) / numWorkers
+		found := make([][]Mismatch, numWorkers)
+		var wg sync.WaitGroup
+		for w := 0; w < numWorkers; w++ {
+			wg.Add(1)
+			startIdx := w * chunkSize
+			endIdx := min(startIdx+chunkSize, Configs[n])
+			go func(w, startIdx, endIdx int) {
+				defer wg.Done()
+				neighbors := make([]int, 0, 32)
+				for g := startIdx; g < endIdx; g++ {
+					a, b := Degoedel(g, n)
+					if Canonical(a, b, n) != g {
+						continue
+					}
+					stored := full.Scores[n][g]
+					if expected := expectedScore(a, b, n, full, neighbors[:0]); expected != stored {
+						found[w] = append(found[w], Mismatch{a, b, n, stored, expected})
+					}
+				}
+			}(w, startIdx, endIdx)
+		}
+		wg.Wait()
+		for _, ms := range found {
+			for _, m := range ms {
+				report(m)
+			}
+			total += len(ms)
+		}
+	}
+	return total
+}
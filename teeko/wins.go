@@ -0,0 +1,128 @@
+package teeko
+
+import "slices"
+
+// Win describes a forced win found in the score tables
+type Win struct {
+	A, B   uint32
+	Pieces int
+	Score  int8
+}
+
+// Distance returns the number of plies until the win
+func (w Win) Distance() int {
+	if w.Score > 0 {
+		return int(ScoreAWin - w.Score)
+	}
+	return int(w.Score - ScoreBWin)
+}
+
+// Scan score tables for the longest forced win of each side;
+// index maps a table offset to its Goedel index
+func longestWins(scores *[9][]int8, index func(n, i int) int) (aWin, bWin *Win) {
+	var longestAWin, longestBWin int8
+	var longestAIdx, longestBIdx, longestAPieces, longestBPieces int
+
+	longestAWin = ScoreAWin // Start at shortest (126), look for smallest positive
+	longestBWin = ScoreBWin // Start at shortest (-126), look for largest negative
+
+	for n := range 9 {
+		table := scores[n]
+		for g, s := range table {
+			// A win: smaller score = longer win (96 is longest forced win)
+			if s > ScoreHeuristicMax && s <= ScoreAWin && s < longestAWin {
+				longestAWin = s
+				longestAIdx = g
+				longestAPieces = n
+			}
+			// B win: larger score (closer to 0) = longer win (-96 is longest)
+			if s < -ScoreHeuristicMax && s >= ScoreBWin && s > longestBWin {
+				longestBWin = s
+				longestBIdx = g
+				longestBPieces = n
+			}
+		}
+	}
+
+	if longestAWin <= ScoreAWin && longestAWin > ScoreHeuristicMax {
+		a, b := Degoedel(index(longestAPieces, longestAIdx), longestAPieces)
+		aWin = &Win{A: a, B: b, Pieces: longestAPieces, Score: longestAWin}
+	}
+	if longestBWin >= ScoreBWin && longestBWin < -ScoreHeuristicMax {
+		a, b := Degoedel(index(longestBPieces, longestBIdx), longestBPieces)
+		bWin = &Win{A: a, B: b, Pieces: longestBPieces, Score: longestBWin}
+	}
+	return
+}
+
+// Longest distance of a forced win, in plies
+const MaxDistance = int(ScoreAWin - ScoreHeuristicMax - 1)
+
+// DistanceHistogram counts the forced wins among the n-piece positions by
+// distance in plies, for the player to move (a) and the opponent (b).
+// Distance 0 counts positions already won.
+func (db *DB) DistanceHistogram(n int) (a, b []int) {
+	return distanceHistogram(db.Scores[n])
+}
+
+func distanceHistogram(table []int8) (a, b []int) {
+	a, b = make([]int, MaxDistance+1), make([]int, MaxDistance+1)
+	for _, s := range table {
+		switch {
+		case s > ScoreHeuristicMax && s <= ScoreAWin:
+			a[ScoreAWin-s]++
+		case s < -ScoreHeuristicMax && s >= ScoreBWin:
+			b[s-ScoreBWin]++
+		}
+	}
+	return a, b
+}
+
+// LongestWinsIn lists up to k of the longest forced wins of each side among the
+// n-piece positions, longest first then in table order. Tables hold one position
+// per symmetry class, so no two wins listed are equivalent under D4.
+func (db *DB) LongestWinsIn(n, k int) (aWins, bWins []Win) {
+	return longestWinsIn(db.Scores[n], n, k, db.Unrank)
+}
+
+func longestWinsIn(table []int8, n, k int, index func(n, i int) int) (aWins, bWins []Win) {
+	aHist, bHist := distanceHistogram(table)
+	// Shortest distance still among the k longest, so that one pass finds them all
+	aMin, bMin := cutoff(aHist, k), cutoff(bHist, k)
+	var aIdx, bIdx []int
+	for i, s := range table {
+		switch {
+		case s > ScoreHeuristicMax && s <= ScoreAWin && int(ScoreAWin-s) >= aMin:
+			aIdx = append(aIdx, i)
+		case s < -ScoreHeuristicMax && s >= ScoreBWin && int(s-ScoreBWin) >= bMin:
+			bIdx = append(bIdx, i)
+		}
+	}
+
+	// Decode only the positions kept, longest first
+	wins := func(idx []int) []Win {
+		slices.SortStableFunc(idx, func(x, y int) int {
+			return Win{Score: table[y]}.Distance() - Win{Score: table[x]}.Distance()
+		})
+		idx = idx[:min(k, len(idx))]
+		ws := make([]Win, len(idx))
+		for j, i := range idx {
+			a, b := Degoedel(index(n, i), n)
+			ws[j] = Win{A: a, B: b, Pieces: n, Score: table[i]}
+		}
+		return ws
+	}
+	return wins(aIdx), wins(bIdx)
+}
+
+// Largest distance d such that at least k wins are d plies or longer,
+// or 1 to keep every win not yet on the board
+func cutoff(hist []int, k int) int {
+	total := 0
+	for d := len(hist) - 1; d > 1; d-- {
+		if total += hist[d]; total >= k {
+			return d
+		}
+	}
+	return 1
+}
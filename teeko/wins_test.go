@@ -0,0 +1,69 @@
+package teeko
+
+import (
+	"slices"
+	"testing"
+)
+
+// A small table of two-piece positions, indexed by Goedel number
+var winsTable = []int8{
+	0,
+	ScoreAWin - 6,
+	0,
+	ScoreAWin - 1,
+	ScoreBWin + 6,
+	ScoreBWin + 2,
+	ScoreHeuristicMax, // heuristic, not a forced win
+	ScoreAWin - 6,
+	ScoreBWin, // already lost
+	ScoreAWin - 26,
+}
+
+func TestDistanceHistogram(t *testing.T) {
+	a, b := distanceHistogram(winsTable)
+	if len(a) != MaxDistance+1 || len(b) != MaxDistance+1 {
+		t.Fatalf("histograms of %d and %d distances, want %d", len(a), len(b), MaxDistance+1)
+	}
+	wantA, wantB := make([]int, MaxDistance+1), make([]int, MaxDistance+1)
+	wantA[1], wantA[6], wantA[26] = 1, 2, 1
+	wantB[0], wantB[2], wantB[6] = 1, 1, 1
+	if !slices.Equal(a, wantA) {
+		t.Errorf("a = %v, want %v", a, wantA)
+	}
+	if !slices.Equal(b, wantB) {
+		t.Errorf("b = %v, want %v", b, wantB)
+	}
+}
+
+func TestLongestWinsIn(t *testing.T) {
+	identity := func(n, i int) int { return i }
+	goedels := func(ws []Win) []int {
+		var gs []int
+		for _, w := range ws {
+			if w.Pieces != 2 || w.Score != winsTable[Goedel(w.A, w.B, 2)] {
+				t.Errorf("win %+v does not match the table", w)
+			}
+			gs = append(gs, Goedel(w.A, w.B, 2))
+		}
+		return gs
+	}
+	for _, tc := range []struct {
+		k            int
+		aWins, bWins []int
+	}{
+		// Ties keep table order
+		{1, []int{9}, []int{4}},
+		{2, []int{9, 1}, []int{4, 5}},
+		{3, []int{9, 1, 7}, []int{4, 5}},
+		// Positions already lost are not listed
+		{10, []int{9, 1, 7, 3}, []int{4, 5}},
+	} {
+		a, b := longestWinsIn(winsTable, 2, tc.k, identity)
+		if got := goedels(a); !slices.Equal(got, tc.aWins) {
+			t.Errorf("k=%d: a wins at %v, want %v", tc.k, got, tc.aWins)
+		}
+		if got := goedels(b); !slices.Equal(got, tc.bWins) {
+			t.Errorf("k=%d: b wins at %v, want %v", tc.k, got, tc.bWins)
+		}
+	}
+}
@@ -162,9 +162,21 @@ function lookupScoreV2(a: number, b: number, n: number): number {
   return canonicalScores[n][rankQuery(n, canonG)];
 }
 
+// V3: canonical flags with a rank directory, one entry per 32-bit word
+function lookupScoreV3(a: number, b: number, n: number): number {
+  const canonG = canonical(a, b, n);
+  const w = canonG >>> 5;
+  const rank =
+    canonicalRanks[n][w] +
+    popcount(canonicalFlags[n][w] & ((1 << (canonG & 31)) - 1));
+  return canonicalScores[n][rank];
+}
+
 // Database
 let checkpoints: Int32Array[] = [];
 let canonicalScores: Int8Array[] = [];
+let canonicalFlags: Uint32Array[] = [];
+let canonicalRanks: Uint32Array[] = [];
 let dbVersion = 0;
 let dbLoading: Promise<void> | null = null;
 let dbLoaded = false;
@@ -262,6 +274,30 @@ async function loadDatabase(): Promise<void> {
           offset += 4;
         }
 
+        // Read scores
+        canonicalScores[n] = new Int8Array(buffer, offset, canonCount);
+        offset += canonCount;
+      }
+    } else if (dbVersion === 3) {
+      // V3 format: canonical flags (64-bit words, read as 32-bit halves)
+      for (let n = 0; n < 9; n++) {
+        const canonCount = view.getUint32(offset, true);
+        offset += 4;
+        const numWords = view.getUint32(offset, true) * 2;
+        offset += 4;
+
+        // Read flags and build the rank directory
+        canonicalFlags[n] = new Uint32Array(numWords);
+        canonicalRanks[n] = new Uint32Array(numWords);
+        let rank = 0;
+        for (let i = 0; i < numWords; i++) {
+          const word = view.getUint32(offset, true);
+          offset += 4;
+          canonicalFlags[n][i] = word;
+          canonicalRanks[n][i] = rank;
+          rank += popcount(word);
+        }
+
         // Read scores
         canonicalScores[n] = new Int8Array(buffer, offset, canonCount);
         offset += canonCount;
@@ -304,8 +340,11 @@ export function isForced(score: number): boolean {
   return score > HEURISTIC_MAX || score < -HEURISTIC_MAX;
 }
 
-// Get score for a position, handling both v1 and v2 formats
+// Get score for a position, handling v1, v2 and v3 formats
 function getScore(a: number, b: number, n: number): number {
+  if (dbVersion === 3) {
+    return lookupScoreV3(a, b, n);
+  }
   if (dbVersion === 2) {
     return lookupScoreV2(a, b, n);
   }